@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+// anonymousWarned remembers the apps already warned about during a batch
+var anonymousWarned sync.Map
+
+// anonymousAllowed reports whether appID is known to allow anonymous downloads
+func anonymousAllowed(appID string) bool {
+	if app, ok := lookupKnownApp(appID); ok && app.Anonymous {
+		return true
+	}
+	return slices.Contains(viper.GetStringSlice("anonymous_apps"), appID)
+}
+
+// checkAnonymous warns before an anonymous download for an app that isn't on
+// the allowlist, since SteamCMD would only fail after using up its retries.
+// With require_force_anonymous set it refuses instead, unless --force-anonymous.
+func checkAnonymous(w io.Writer, appID string) error {
+	if viper.GetString("username") != "" || viper.GetBool("force_anonymous") || anonymousAllowed(appID) {
+		return nil
+	}
+
+	if viper.GetBool("require_force_anonymous") {
+		return fmt.Errorf("app %s is not known to allow anonymous downloads; log in and pass --username, add it to anonymous_apps, or use --force-anonymous: %w",
+			appID, steamcmd.ErrNotLoggedOn)
+	}
+
+	if _, warned := anonymousWarned.LoadOrStore(appID, true); !warned {
+		fmt.Fprintf(w, "%s App %s is not known to allow anonymous downloads; this will likely fail without --username.\n", iconWarn, appID)
+		fmt.Fprintf(w, "%s Add it to anonymous_apps in the config if it works anonymously, or pass --force-anonymous to hide this warning. See 'workshop apps'.\n", iconTip)
+	}
+	return nil
+}
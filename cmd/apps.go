@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// knownApp is what the tool knows about a game without asking Steam
+type knownApp struct {
+	AppID     string `json:"app_id"`
+	Name      string `json:"name"`
+	Anonymous bool   `json:"anonymous"` // Workshop items download with an anonymous login
+	Source    string `json:"source"`    // "built-in" or "config"
+}
+
+// knownApps lists the games whose anonymous download support is known. Apps
+// with Anonymous set have dedicated servers that fetch workshop content with
+// an anonymous SteamCMD login; the others need an account that owns the game.
+// The anonymous_apps setting adds to them.
+var knownApps = []knownApp{
+	{AppID: "4000", Name: "Garry's Mod", Anonymous: true},
+	{AppID: "108600", Name: "Project Zomboid", Anonymous: true},
+	{AppID: "244850", Name: "Space Engineers", Anonymous: true},
+	{AppID: "255710", Name: "Cities: Skylines"},
+	{AppID: "281990", Name: "Stellaris"},
+	{AppID: "322330", Name: "Don't Starve Together", Anonymous: true},
+	{AppID: "346110", Name: "ARK: Survival Evolved", Anonymous: true},
+	{AppID: "440900", Name: "Conan Exiles", Anonymous: true},
+}
+
+// appsCmd represents the apps command
+var appsCmd = &cobra.Command{
+	Use:   "apps",
+	Short: "List the games the tool knows about",
+	Long: `List the games the tool has built-in knowledge of, and whether their
+workshop items download anonymously or need 'workshop login' with an account
+that owns the game. Apps added with the anonymous_apps setting are included.
+
+Examples:
+  workshop apps
+  workshop apps --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listApps()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(appsCmd)
+
+	appsCmd.Flags().String("format", "table", "Output format: table or json")
+	bindFlag("apps_format", appsCmd.Flags().Lookup("format"))
+}
+
+// lookupKnownApp returns the built-in knowledge about an app
+func lookupKnownApp(appID string) (knownApp, bool) {
+	for _, app := range knownApps {
+		if app.AppID == appID {
+			return app, true
+		}
+	}
+	return knownApp{}, false
+}
+
+// allKnownApps returns the built-in apps followed by those added in the config
+func allKnownApps() []knownApp {
+	apps := make([]knownApp, 0, len(knownApps))
+	for _, app := range knownApps {
+		app.Source = "built-in"
+		apps = append(apps, app)
+	}
+
+	for _, appID := range viper.GetStringSlice("anonymous_apps") {
+		i := slices.IndexFunc(apps, func(app knownApp) bool { return app.AppID == appID })
+		if i >= 0 {
+			if !apps[i].Anonymous {
+				apps[i].Anonymous = true
+				apps[i].Source = "config"
+			}
+			continue
+		}
+		apps = append(apps, knownApp{AppID: appID, Anonymous: true, Source: "config"})
+	}
+
+	return apps
+}
+
+func listApps() error {
+	format := viper.GetString("apps_format")
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid --format %q: must be table or json", format)
+	}
+
+	apps := allKnownApps()
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(apps)
+	}
+
+	fmt.Printf("%-8s  %-24s  %-10s  %s\n", "APP ID", "NAME", "ANONYMOUS", "SOURCE")
+	for _, app := range apps {
+		anonymous := "no"
+		if app.Anonymous {
+			anonymous = "yes"
+		}
+		fmt.Printf("%-8s  %-24s  %-10s  %s\n", app.AppID, app.Name, anonymous, app.Source)
+	}
+
+	fmt.Printf("\n%s Apps marked 'no' need 'workshop login' with an account that owns the game, then --username.\n", iconTip)
+	return nil
+}
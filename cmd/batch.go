@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/manifest"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+// batchItem identifies one workshop item of a multi-item download. The same
+// workshop ID may be downloaded for several apps, so items are always keyed
+// by the pair.
+type batchItem struct {
+	AppID      string
+	WorkshopID string
+}
+
+// String formats the item as app/workshop, unambiguous across apps
+func (b batchItem) String() string {
+	return b.AppID + "/" + b.WorkshopID
+}
+
+// jsonLinesWriter writes one JSON object per line. Writes are serialized so
+// results from concurrent downloads never interleave.
+type jsonLinesWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newJSONLinesWriter(w io.Writer) *jsonLinesWriter {
+	return &jsonLinesWriter{enc: json.NewEncoder(w)}
+}
+
+// Write emits v as a single line
+func (w *jsonLinesWriter) Write(v any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(v)
+}
+
+// downloadBatch downloads each item read from source in turn, continuing past
+// failures, and returns an error summarizing the items that failed
+func downloadBatch(items []batchItem, source string) error {
+	// In JSON mode stdout carries only the results
+	w := humanOutput()
+	var emit *jsonLinesWriter
+	if jsonOutput() {
+		emit = newJSONLinesWriter(os.Stdout)
+	}
+
+	fmt.Fprintf(w, "Downloading %d workshop items from %s\n", len(items), source)
+
+	workers, err := batchConcurrency()
+	if err != nil {
+		return err
+	}
+	if workers > 1 && viper.GetBool("persistent") {
+		fmt.Fprintf(w, "%s --persistent reuses a single SteamCMD process; downloading one item at a time\n", iconWarn)
+		workers = 1
+	}
+	workers = min(workers, len(items))
+	if workers > 1 {
+		fmt.Fprintf(w, "Running %d downloads in parallel\n", workers)
+	}
+
+	var failed []batchItem
+	maxConsecutive := viper.GetInt("max_consecutive_failures")
+	consecutive := 0
+
+	summary := &batchSummary{Total: len(items)}
+	start := time.Now()
+	logger.Info("batch started", "source", source, "items", len(items), "workers", workers)
+	finish := func() error {
+		summary.finish(time.Since(start))
+		summary.print(w)
+		logger.Info("batch finished", "source", source, "succeeded", summary.Succeeded, "skipped", summary.Skipped,
+			"failed", summary.Failed, "aborted", summary.Aborted, "bytes", summary.BytesTotal, "seconds", summary.ElapsedSeconds)
+		if emit != nil {
+			if err := emit.Write(map[string]*batchSummary{"summary": summary}); err != nil {
+				return fmt.Errorf("failed to write summary: %w", err)
+			}
+		}
+		return nil
+	}
+
+	// Workers take items in order; results are counted under mu
+	var (
+		mu        sync.Mutex
+		processed int
+		attempted = make([]bool, len(items))
+		aborted   bool
+		writeErr  error
+		wg        sync.WaitGroup
+	)
+	next := make(chan int)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				mu.Lock()
+				stop := aborted || writeErr != nil
+				if !stop {
+					processed++
+					attempted[i] = true
+				}
+				mu.Unlock()
+				if stop {
+					continue
+				}
+
+				// Parallel items print their output whole once done, so it doesn't interleave
+				out := w
+				var buf bytes.Buffer
+				if workers > 1 {
+					out = &buf
+				}
+
+				item := items[i]
+				fmt.Fprintf(out, "\n[%d/%d] Workshop item %s (app %s)\n", i+1, len(items), item.WorkshopID, item.AppID)
+				result, err := downloadItem(out, []string{item.AppID, item.WorkshopID})
+
+				mu.Lock()
+				if out == &buf {
+					buf.WriteTo(w)
+				}
+				summary.add(result)
+				if err != nil {
+					fmt.Fprintf(w, "%s %s: %v\n", iconErr, item, err)
+					failed = append(failed, item)
+				}
+				// Only failures pointing at a Steam outage count towards
+				// --max-consecutive-failures
+				if steamcmd.IsOutage(err) {
+					consecutive++
+				} else {
+					consecutive = 0
+				}
+
+				if emit != nil && writeErr == nil {
+					if result.AppID == "" {
+						result.AppID, result.WorkshopID = item.AppID, item.WorkshopID
+					}
+					if err := emit.Write(result); err != nil {
+						writeErr = fmt.Errorf("failed to write result: %w", err)
+					}
+				}
+
+				// Stop burning retries on every remaining item during an outage
+				if maxConsecutive > 0 && consecutive >= maxConsecutive {
+					aborted = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range items {
+		mu.Lock()
+		stop := aborted || writeErr != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if err := writeFailedOut(w, items, attempted, failed, source); err != nil {
+		fmt.Fprintf(w, "%s %v\n", iconWarn, err)
+	}
+
+	if aborted && processed < len(items) {
+		fmt.Fprintf(w, "\n%s %d items failed in a row with network or server errors; Steam appears to be down. Aborting the remaining %d items.\n",
+			iconErr, consecutive, len(items)-processed)
+		summary.Aborted = true
+		if err := finish(); err != nil {
+			return err
+		}
+		return fmt.Errorf("aborted after %d consecutive failures (%d/%d items downloaded)",
+			consecutive, processed-len(failed), len(items))
+	}
+
+	fmt.Fprintf(w, "\nDownloaded %d/%d items\n", len(items)-len(failed), len(items))
+	if err := finish(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d item(s) failed: %v", len(failed), failed)
+	}
+
+	return nil
+}
+
+// writeFailedOut writes the items that failed or were never attempted to the
+// --failed-out file, in input order, so they can be retried with --from-file.
+// The file is written even when nothing failed, so an old list is never
+// mistaken for the outcome of this run.
+func writeFailedOut(w io.Writer, items []batchItem, attempted []bool, failed []batchItem, source string) error {
+	path := viper.GetString("failed_out")
+	if path == "" {
+		return nil
+	}
+
+	failedSet := make(map[batchItem]bool, len(failed))
+	for _, item := range failed {
+		failedSet[item] = true
+	}
+
+	var retry []manifest.Item
+	for i, item := range items {
+		if failedSet[item] || !attempted[i] {
+			retry = append(retry, manifest.Item{AppID: item.AppID, WorkshopID: item.WorkshopID})
+		}
+	}
+
+	header := fmt.Sprintf("%d item(s) of %s to retry, written %s", len(retry), source, time.Now().UTC().Format(time.RFC3339))
+	if err := manifest.SaveItemList(path, header, retry); err != nil {
+		return err
+	}
+	if len(retry) > 0 {
+		fmt.Fprintf(w, "%s Wrote %d item(s) to retry to %s: workshop download --from-file %s\n", iconTip, len(retry), path, path)
+	}
+	return nil
+}
+
+// maxAutoConcurrency caps --concurrency auto. Workers take turns running
+// SteamCMD, so more of them only overlap lookups and output copies, and
+// Steam throttles addresses that hit the Web API hard; the cap stays low
+// whatever the machine.
+const maxAutoConcurrency = 3
+
+// batchConcurrency resolves --concurrency to a worker count: a positive
+// number as given, or for auto half the CPUs, between 1 and maxAutoConcurrency
+func batchConcurrency() (int, error) {
+	value := viper.GetString("concurrency")
+	if value == "auto" {
+		return max(1, min(runtime.NumCPU()/2, maxAutoConcurrency)), nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid --concurrency %q: must be a positive number or auto", value)
+	}
+	return n, nil
+}
+
+// batchSummary totals the results of a batch download
+type batchSummary struct {
+	Total          int     `json:"total"`
+	Succeeded      int     `json:"succeeded"`
+	Skipped        int     `json:"skipped"`
+	Failed         int     `json:"failed"`
+	Aborted        bool    `json:"aborted,omitempty"`
+	BytesTotal     int64   `json:"bytes_downloaded"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	MBPerSecond    float64 `json:"mb_per_second"`
+
+	// Seconds spent in each download phase across all items, with --timings
+	PhaseSeconds map[string]float64 `json:"phase_seconds,omitempty"`
+
+	elapsed time.Duration
+}
+
+// add counts one item's result. Only downloaded items count towards the
+// bytes, so skipped items don't inflate the throughput.
+func (s *batchSummary) add(result *downloadResult) {
+	for _, t := range result.Timings {
+		if s.PhaseSeconds == nil {
+			s.PhaseSeconds = make(map[string]float64)
+		}
+		s.PhaseSeconds[t.Phase] += t.Seconds
+	}
+
+	switch result.Status {
+	case statusDownloaded:
+		s.Succeeded++
+		s.BytesTotal += result.SizeBytes
+	case statusSkipped:
+		s.Skipped++
+	default:
+		s.Failed++
+	}
+}
+
+// finish records the wall-clock time of the batch and the resulting throughput
+func (s *batchSummary) finish(elapsed time.Duration) {
+	s.elapsed = elapsed.Round(time.Millisecond)
+	s.ElapsedSeconds = s.elapsed.Seconds()
+	if elapsed > 0 {
+		s.MBPerSecond = float64(s.BytesTotal) / (1024 * 1024) / elapsed.Seconds()
+	}
+}
+
+func (s *batchSummary) print(w io.Writer) {
+	fmt.Fprintf(w, "Summary: %d succeeded, %d skipped, %d failed of %d items\n", s.Succeeded, s.Skipped, s.Failed, s.Total)
+	fmt.Fprintf(w, "Transferred %s in %s (%.2f MB/s)\n", formatBytes(s.BytesTotal), s.elapsed, s.MBPerSecond)
+	printPhaseTotals(w, s.PhaseSeconds)
+}
+
+// downloadFromJSON downloads every item of a collection JSON export
+func downloadFromJSON(path string) error {
+	entries, err := manifest.LoadCollectionJSON(path)
+	if err != nil {
+		return err
+	}
+	return downloadEntries(entries, path)
+}
+
+// downloadFromFile downloads every item of a plain-text item list
+func downloadFromFile(path string) error {
+	entries, err := manifest.LoadItemList(path)
+	if err != nil {
+		return err
+	}
+	return downloadEntries(entries, path)
+}
+
+// downloadEntries downloads the items read from source once each, only the
+// first --limit of them when it's set
+func downloadEntries(entries []manifest.Item, source string) error {
+	items := uniqueItems(entries)
+	if len(items) == 0 {
+		fmt.Fprintf(humanOutput(), "%s contains no workshop items.\n", source)
+		return nil
+	}
+
+	if limit := viper.GetInt("limit"); limit > 0 && limit < len(items) {
+		fmt.Fprintf(humanOutput(), "%s Limited to the first %d of %d items (--limit)\n", iconTip, limit, len(items))
+		items = items[:limit]
+	}
+
+	return downloadBatch(items, source)
+}
+
+// uniqueItems returns the batch items of entries in order, without
+// duplicates, keyed by the (app, workshop item) pair
+func uniqueItems(entries []manifest.Item) []batchItem {
+	seen := make(map[batchItem]bool)
+	var items []batchItem
+	for _, entry := range entries {
+		item := batchItem{AppID: entry.AppID, WorkshopID: entry.WorkshopID}
+		if seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/webapi"
+)
+
+// Update check outcomes
+const (
+	updateAvailable     = "update available"
+	updateUpToDate      = "up to date"
+	updateUnknown       = "unknown"
+	updateNotDownloaded = "not downloaded"
+)
+
+// checkForUpdate compares the size of the local copy of an item with the size
+// reported by the Steam Web API. It's a quick heuristic that never runs SteamCMD.
+func checkForUpdate(w io.Writer, client *steamcmd.Client, result *downloadResult) error {
+	result.Status = statusSkipped
+
+	exists, path, err := client.CheckWorkshopItemExists(result.AppID, result.WorkshopID)
+	if err != nil {
+		return fmt.Errorf("failed to check if item exists: %w", err)
+	}
+	if !exists {
+		result.Update = updateNotDownloaded
+		fmt.Fprintf(w, "Workshop item %s: %s\n", result.WorkshopID, updateNotDownloaded)
+		return nil
+	}
+
+	result.Path = path
+	result.SizeBytes = getDirSize(path)
+
+	details, err := webapi.GetPublishedFileDetails(result.WorkshopID)
+	switch {
+	case err != nil:
+		result.Update = updateUnknown
+		fmt.Fprintf(w, "%s Could not get the published size: %v\n", iconWarn, err)
+	case details.SizeBytes == 0:
+		result.Update = updateUnknown
+	case details.SizeBytes == result.SizeBytes:
+		result.Update = updateUpToDate
+	default:
+		result.Update = updateAvailable
+	}
+
+	fmt.Fprintf(w, "Workshop item %s: %s (local %s", result.WorkshopID, result.Update, formatBytes(result.SizeBytes))
+	if details != nil && details.SizeBytes > 0 {
+		fmt.Fprintf(w, ", published %s", formatBytes(details.SizeBytes))
+	}
+	fmt.Fprintln(w, ")")
+
+	return nil
+}
+
+// checkIfNewer decides whether --if-newer downloads an item: when it isn't
+// downloaded yet, when Steam reports a publish time later than that of the
+// installed version, or when either time is unknown. Unchanged items are
+// recorded in result as skipped, without running SteamCMD.
+func checkIfNewer(w io.Writer, client *steamcmd.Client, result *downloadResult) bool {
+	exists, path, err := client.CheckWorkshopItemExists(result.AppID, result.WorkshopID)
+	if err != nil || !exists {
+		return true
+	}
+
+	installed, err := client.GetInstalledItem(result.AppID, result.WorkshopID)
+	var installedAt time.Time
+	if err == nil {
+		if seconds, err := strconv.ParseInt(installed.TimeUpdated, 10, 64); err == nil && seconds > 0 {
+			installedAt = time.Unix(seconds, 0).UTC()
+		}
+	}
+	if installedAt.IsZero() {
+		fmt.Fprintf(w, "%s The installed version of %s isn't recorded; downloading it again\n", iconWarn, result.WorkshopID)
+		return true
+	}
+
+	details, err := webapi.GetPublishedFileDetails(result.WorkshopID)
+	if err != nil || details.TimeUpdated.IsZero() {
+		if err == nil {
+			err = fmt.Errorf("no update time reported")
+		}
+		fmt.Fprintf(w, "%s Could not get the last update time of %s: %v; downloading it\n", iconWarn, result.WorkshopID, err)
+		return true
+	}
+
+	if details.TimeUpdated.After(installedAt) {
+		fmt.Fprintf(w, "Workshop item %s was updated %s (installed version from %s)\n", result.WorkshopID,
+			details.TimeUpdated.Local().Format(time.DateTime), installedAt.Local().Format(time.DateTime))
+		return true
+	}
+
+	result.Status = statusSkipped
+	result.Update = updateUpToDate
+	result.Path = path
+	result.SizeBytes = getDirSize(path)
+	fmt.Fprintf(w, "%s Workshop item %s is up to date (last updated %s)\n", iconOK, result.WorkshopID, details.TimeUpdated.Local().Format(time.DateTime))
+	return false
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"strings"
 
-	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -26,8 +25,13 @@ The command will clean:
 - Workshop temp folder
 - Workshop content folder (if --all flag is used)
 
+Use --app-id to only clean the cache of a single game.
+Use --dry-run to see how much space each directory would free without removing anything.
 Use --force to skip confirmation prompt.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := lockSteamCMD(); err != nil {
+			return err
+		}
 		return cleanWorkshop()
 	},
 }
@@ -37,20 +41,32 @@ func init() {
 
 	cleanCmd.Flags().BoolP("force", "f", false, "Force clean without confirmation prompt")
 	cleanCmd.Flags().BoolP("all", "a", false, "Also remove downloaded workshop content (not just cache)")
-	viper.BindPFlag("force_clean", cleanCmd.Flags().Lookup("force"))
-	viper.BindPFlag("clean_all", cleanCmd.Flags().Lookup("all"))
+	cleanCmd.Flags().String("app-id", "", "Only clean cache directories of this Steam App ID")
+	cleanCmd.Flags().Bool("dry-run", false, "Report what would be removed and how much space it would free, without removing anything")
+	bindFlag("force_clean", cleanCmd.Flags().Lookup("force"))
+	bindFlag("clean_all", cleanCmd.Flags().Lookup("all"))
+	bindFlag("clean_app_id", cleanCmd.Flags().Lookup("app-id"))
+	bindFlag("clean_dry_run", cleanCmd.Flags().Lookup("dry-run"))
 }
 
 func cleanWorkshop() error {
 	// Create SteamCMD client to get paths
-	steamcmdDir := viper.GetString("steamcmd_dir")
-	client, err := steamcmd.NewClient(steamcmdDir)
+	client, err := newSteamCMDClient(os.Stdout)
 	if err != nil {
 		return fmt.Errorf("failed to create SteamCMD client: %w", err)
 	}
 
-	// Get all workshop cache paths
-	cachePaths := client.GetWorkshopCachePaths()
+	// Get all workshop cache paths, or only those of the requested app
+	var cachePaths []string
+	appID := viper.GetString("clean_app_id")
+	if appID != "" {
+		if err := ValidateAppID(appID); err != nil {
+			return err
+		}
+		cachePaths = client.GetAppWorkshopCachePaths(appID)
+	} else {
+		cachePaths = client.GetWorkshopCachePaths()
+	}
 
 	if len(cachePaths) == 0 {
 		fmt.Println("No workshop cache directories found to clean.")
@@ -66,7 +82,19 @@ func cleanWorkshop() error {
 	}
 
 	if len(existingPaths) == 0 {
-		fmt.Println("No workshop cache directories found to clean.")
+		if appID != "" {
+			fmt.Printf("No workshop cache directories found for app %s.\n", appID)
+		} else {
+			fmt.Println("No workshop cache directories found to clean.")
+		}
+		return nil
+	}
+
+	cleanAll := viper.GetBool("clean_all")
+
+	// Report sizes and stop before touching anything
+	if viper.GetBool("clean_dry_run") {
+		printCleanPlan(existingPaths, cleanAll)
 		return nil
 	}
 
@@ -78,9 +106,8 @@ func cleanWorkshop() error {
 	fmt.Println()
 
 	// If --all flag is used, also show content directories
-	cleanAll := viper.GetBool("clean_all")
 	if cleanAll {
-		fmt.Println("⚠️  --all flag used: Downloaded workshop content will also be removed!")
+		fmt.Printf("%s --all flag used: Downloaded workshop content will also be removed!\n", iconWarn)
 		fmt.Println("   You will need to re-download any workshop items.")
 		fmt.Println()
 	}
@@ -108,7 +135,7 @@ func cleanWorkshop() error {
 
 	for _, path := range existingPaths {
 		// Skip content directories unless --all is used
-		if !cleanAll && strings.Contains(path, "content") {
+		if !cleanAll && isContentPath(path) {
 			continue
 		}
 
@@ -122,16 +149,42 @@ func cleanWorkshop() error {
 
 	// Report results
 	if len(errors) > 0 {
-		fmt.Printf("\n❌ Completed with %d errors:\n", len(errors))
+		fmt.Printf("\n%s Completed with %d errors:\n", iconErr, len(errors))
 		for _, errMsg := range errors {
 			fmt.Printf("  %s\n", errMsg)
 		}
 	}
 
 	if removedCount > 0 {
-		fmt.Printf("\n✅ Successfully cleaned %d workshop cache directories.\n", removedCount)
+		fmt.Printf("\n%s Successfully cleaned %d workshop cache directories.\n", iconOK, removedCount)
 		fmt.Println("This should fix CWorkThreadPool errors in SteamCMD.")
 	}
 
 	return nil
 }
+
+// isContentPath reports whether path holds downloaded content rather than cache,
+// which is only removed with --all
+func isContentPath(path string) bool {
+	return strings.Contains(path, "content")
+}
+
+// printCleanPlan reports the size of each directory clean would remove and the total
+func printCleanPlan(paths []string, cleanAll bool) {
+	fmt.Println("Dry run: nothing will be removed.")
+	fmt.Println()
+
+	var total int64
+	for _, path := range paths {
+		if !cleanAll && isContentPath(path) {
+			fmt.Printf("  %10s  %s (kept; use --all to remove)\n", "-", path)
+			continue
+		}
+
+		size := getDirSize(path)
+		total += size
+		fmt.Printf("  %10s  %s\n", formatBytes(size), path)
+	}
+
+	fmt.Printf("\nTotal reclaimable: %s\n", formatBytes(total))
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the tool's configuration",
+	Long: `Inspect the configuration resolved from flags, environment variables,
+the config file and built-in defaults.`,
+}
+
+// configDebugCmd represents the config debug command
+var configDebugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Print the resolved effective configuration",
+	Long: `Print every setting the tool will use, annotated with where its value
+came from. Precedence, highest first: flag, env, file, default.
+
+Example:
+  workshop config debug
+  workshop config debug --download-dir /tmp/mods`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printEffectiveConfig()
+	},
+}
+
+// configProfilesCmd represents the config profiles command
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List the profiles defined in the config file",
+	Long: `List the profiles defined under 'profiles' in the config file, with
+the settings each one overrides. Select one with --profile <name>.
+
+Example:
+  workshop config profiles
+  workshop --profile server-a download 108600 2503622437`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printProfiles()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configDebugCmd)
+	configCmd.AddCommand(configProfilesCmd)
+}
+
+func printEffectiveConfig() error {
+	if file := viper.ConfigFileUsed(); file != "" {
+		fmt.Printf("Config file: %s\n", file)
+	} else {
+		fmt.Println("Config file: (none found)")
+	}
+	fmt.Println()
+
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	width := 0
+	for _, key := range keys {
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+
+	for _, key := range keys {
+		fmt.Printf("%-*s = %-40v [%s]\n", width, key, viper.Get(key), configSource(key))
+	}
+
+	return nil
+}
+
+// configSource reports where viper resolved the value of key from,
+// following viper's precedence order. A renamed key may be set by its old name.
+func configSource(key string) string {
+	if flag, ok := flagBindings[key]; ok && flag.Changed {
+		return "flag --" + flag.Name
+	}
+
+	names := []string{key}
+	for old, current := range renamedKeys {
+		if current == key {
+			names = append(names, old)
+		}
+	}
+	for _, name := range names {
+		if _, ok := os.LookupEnv(envName(name)); ok {
+			return "env " + envName(name)
+		}
+	}
+	if profile := viper.GetString("profile"); profile != "" {
+		for _, name := range names {
+			if viper.InConfig("profiles." + profile + "." + name) {
+				return "profile " + profile
+			}
+		}
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
+
+func printProfiles() error {
+	profiles := viper.GetStringMap("profiles")
+	if len(profiles) == 0 {
+		fmt.Println("No profiles defined. Add them under 'profiles:' in the config file.")
+		return nil
+	}
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	active := viper.GetString("profile")
+	for _, name := range names {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, name)
+
+		settings, _ := profiles[name].(map[string]any)
+		keys := make([]string, 0, len(settings))
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("    %s = %v\n", key, settings[key])
+		}
+	}
+
+	return nil
+}
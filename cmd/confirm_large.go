@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/webapi"
+	"github.com/spf13/viper"
+)
+
+// byteUnits are the suffixes parseByteSize accepts, in bytes
+var byteUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// parseByteSize parses a size such as "500MB", "12.5GB" or "2GiB"; a plain
+// number is in bytes
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	}
+
+	multiplier, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q (use B, KB, MB, GB, TB or KiB, MiB, GiB, TiB)", s, s[i:])
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	size := value * float64(multiplier)
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return int64(size), nil
+}
+
+// confirmMu keeps the prompts of parallel batch downloads from interleaving
+var confirmMu sync.Mutex
+
+// confirmLargeDownload asks before downloading an item the Web API reports
+// as larger than --confirm-large, and reports whether to go ahead. There's
+// no prompt with --yes or --force, without a terminal to answer it, or when
+// the size can't be looked up.
+func confirmLargeDownload(w io.Writer, workshopID string) (bool, error) {
+	threshold := viper.GetString("confirm_large")
+	if threshold == "" {
+		return true, nil
+	}
+	limit, err := parseByteSize(threshold)
+	if err != nil {
+		return false, fmt.Errorf("--confirm-large: %w", err)
+	}
+	if viper.GetBool("yes") || viper.GetBool("force_download") {
+		return true, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return true, nil
+	}
+
+	details, err := webapi.GetPublishedFileDetails(workshopID)
+	if err != nil || details.SizeBytes == 0 {
+		fmt.Fprintf(w, "%s Couldn't look up the size of workshop item %s; downloading without asking\n", iconWarn, workshopID)
+		return true, nil
+	}
+	if details.SizeBytes <= limit {
+		return true, nil
+	}
+
+	confirmMu.Lock()
+	defer confirmMu.Unlock()
+
+	// The prompt skips w, which holds a parallel item's output until it's done
+	fmt.Fprintf(humanOutput(), "Workshop item %s is %s. Continue? (y/N): ", workshopID, formatBytes(details.SizeBytes))
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes", nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "1024", want: 1024},
+		{input: "500MB", want: 500 * 1000 * 1000},
+		{input: "12.5 gb", want: 12_500_000_000},
+		{input: "2GiB", want: 2 << 30},
+		{input: "5XB", wantErr: true},
+		{input: "GB", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// contentFile is one file of a downloaded item
+type contentFile struct {
+	Path string // Relative to the item directory
+	Size int64
+}
+
+// contentSummary describes what a downloaded item contains
+type contentSummary struct {
+	Files     []contentFile
+	TotalSize int64
+}
+
+// summarizeContents walks the item directory once, like getDirSize, but keeps
+// every file so the largest ones can be reported
+func summarizeContents(root string) contentSummary {
+	var summary contentSummary
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Continue on errors
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		summary.Files = append(summary.Files, contentFile{Path: filepath.ToSlash(rel), Size: info.Size()})
+		summary.TotalSize += info.Size()
+		return nil
+	})
+
+	return summary
+}
+
+// largest returns the n largest files, biggest first
+func (s contentSummary) largest(n int) []contentFile {
+	files := append([]contentFile(nil), s.Files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Size > files[j].Size
+	})
+	if len(files) > n {
+		files = files[:n]
+	}
+	return files
+}
+
+// printContents prints the --show-contents summary of a downloaded item
+func printContents(w io.Writer, root string) {
+	summary := summarizeContents(root)
+
+	fmt.Fprintf(w, "\n%s Contents: %d file(s), %s\n", iconDir, len(summary.Files), formatBytes(summary.TotalSize))
+
+	if top := viper.GetInt("top_files"); top > 0 && len(summary.Files) > 0 {
+		fmt.Fprintf(w, "Largest files:\n")
+		for _, f := range summary.largest(top) {
+			fmt.Fprintf(w, "  %10s  %s\n", formatBytes(f.Size), f.Path)
+		}
+	}
+
+	if depth := viper.GetInt("tree_depth"); depth > 0 {
+		fmt.Fprintln(w, "Tree:")
+		printTree(w, root, "  ", depth)
+	}
+}
+
+// printTree prints the directory tree of dir down to depth levels, directories first
+func printTree(w io.Writer, dir, indent string, depth int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name())
+	})
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			fmt.Fprintf(w, "%s%s/\n", indent, entry.Name())
+			if depth > 1 {
+				printTree(w, filepath.Join(dir, entry.Name()), indent+"  ", depth-1)
+			}
+			continue
+		}
+
+		size := int64(0)
+		if info, err := entry.Info(); err == nil {
+			size = info.Size()
+		}
+		fmt.Fprintf(w, "%s%s (%s)\n", indent, entry.Name(), formatBytes(size))
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/scraper"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// depsCmd represents the deps command
+var depsCmd = &cobra.Command{
+	Use:   "deps <workshop URL or ID>",
+	Short: "List the workshop items an item requires",
+	Long: `List the items shown under "Required items" on a workshop item's page.
+With --recursive the dependencies of those items are resolved too, listed so
+that every item comes after the items it requires.
+
+To download an item together with everything it requires, use
+'workshop download <item> --with-deps'.
+
+Examples:
+  workshop deps 2503622437
+  workshop deps https://steamcommunity.com/sharedfiles/filedetails/?id=2503622437 --recursive`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printDependencies(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(depsCmd)
+
+	depsCmd.Flags().BoolP("recursive", "r", false, "Also resolve the dependencies of dependencies")
+	bindFlag("deps_recursive", depsCmd.Flags().Lookup("recursive"))
+}
+
+// dependency is a required item found while resolving dependencies
+type dependency struct {
+	scraper.Dependency
+	RequiredBy string // Workshop ID of the item that listed it first
+}
+
+// scrapeDependencies fetches the "Required items" of a workshop item's page
+func scrapeDependencies(workshopID string) ([]scraper.Dependency, error) {
+	info, err := scraper.ScrapeWorkshopPage(scraper.ItemURL(workshopID))
+	if err != nil && !errors.Is(err, scraper.ErrAppIDNotFound) {
+		return nil, fmt.Errorf("failed to read the workshop page of %s: %w", workshopID, err)
+	}
+	return info.Dependencies, nil
+}
+
+// resolveDependencies returns the items workshopID requires, as listed by
+// fetch. With recursive their own dependencies are included, and every item
+// comes after the items it requires. Each item is listed once, even when
+// dependencies are circular.
+func resolveDependencies(workshopID string, recursive bool, fetch func(string) ([]scraper.Dependency, error)) ([]dependency, error) {
+	seen := map[string]bool{workshopID: true}
+	var resolved []dependency
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		deps, err := fetch(id)
+		if err != nil {
+			return err
+		}
+
+		for _, dep := range deps {
+			if seen[dep.WorkshopID] {
+				continue
+			}
+			seen[dep.WorkshopID] = true
+
+			if recursive {
+				if err := visit(dep.WorkshopID); err != nil {
+					return err
+				}
+			}
+			resolved = append(resolved, dependency{Dependency: dep, RequiredBy: id})
+		}
+		return nil
+	}
+
+	if err := visit(workshopID); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// workshopIDArg returns the workshop ID of a workshop URL or bare ID
+func workshopIDArg(arg string) (string, error) {
+	if isNumeric(arg) {
+		return arg, nil
+	}
+	return parseWorkshopURL(arg)
+}
+
+func printDependencies(arg string) error {
+	workshopID, err := workshopIDArg(arg)
+	if err != nil {
+		return err
+	}
+
+	deps, err := resolveDependencies(workshopID, viper.GetBool("deps_recursive"), scrapeDependencies)
+	if err != nil {
+		return err
+	}
+
+	if len(deps) == 0 {
+		fmt.Printf("Item %s lists no required items\n", workshopID)
+		return nil
+	}
+
+	fmt.Printf("Item %s requires %d item(s):\n", workshopID, len(deps))
+	for _, dep := range deps {
+		line := fmt.Sprintf("  %-12s %s", dep.WorkshopID, dep.Title)
+		if dep.RequiredBy != workshopID {
+			line += fmt.Sprintf(" (required by %s)", dep.RequiredBy)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// downloadWithDependencies downloads an item after every item it requires,
+// recursively. Dependencies are taken to belong to the same game.
+func downloadWithDependencies(args []string) error {
+	w := humanOutput()
+	appID, workshopID, _, err := parseDownloadInput(w, args)
+	if err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+
+	fmt.Fprintln(w, "Resolving required items...")
+	deps, err := resolveDependencies(workshopID, true, scrapeDependencies)
+	if err != nil {
+		return err
+	}
+	if len(deps) == 0 {
+		fmt.Fprintf(w, "Item %s lists no required items\n", workshopID)
+		return downloadWorkshopItem([]string{appID, workshopID})
+	}
+
+	items := make([]batchItem, 0, len(deps)+1)
+	for _, dep := range deps {
+		fmt.Fprintf(w, "  requires %s %s\n", dep.WorkshopID, dep.Title)
+		items = append(items, batchItem{AppID: appID, WorkshopID: dep.WorkshopID})
+	}
+	items = append(items, batchItem{AppID: appID, WorkshopID: workshopID})
+
+	return downloadBatch(items, fmt.Sprintf("item %s and its dependencies", workshopID))
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/scraper"
+)
+
+func TestResolveDependencies(t *testing.T) {
+	// 1 requires 2 and 3; 2 requires 4 and, circularly, 1; 3 requires 4
+	pages := map[string][]scraper.Dependency{
+		"1": {{WorkshopID: "2"}, {WorkshopID: "3"}},
+		"2": {{WorkshopID: "4"}, {WorkshopID: "1"}},
+		"3": {{WorkshopID: "4"}},
+	}
+	fetch := func(id string) ([]scraper.Dependency, error) { return pages[id], nil }
+
+	tests := []struct {
+		recursive bool
+		want      []string
+	}{
+		{false, []string{"2", "3"}},
+		{true, []string{"4", "2", "3"}},
+	}
+
+	for _, tt := range tests {
+		deps, err := resolveDependencies("1", tt.recursive, fetch)
+		if err != nil {
+			t.Fatalf("resolveDependencies() unexpected error = %v", err)
+		}
+
+		var got []string
+		for _, dep := range deps {
+			got = append(got, dep.WorkshopID)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("resolveDependencies(recursive=%v) = %v, want %v", tt.recursive, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("resolveDependencies(recursive=%v) = %v, want %v", tt.recursive, got, tt.want)
+				break
+			}
+		}
+	}
+}
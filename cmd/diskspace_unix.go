@@ -0,0 +1,24 @@
+//go:build !windows
+
+package cmd
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the bytes available to this user on the filesystem holding path
+func freeSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// sameFilesystem reports whether two existing paths are on the same
+// filesystem, so moving between them is a rename rather than a copy
+func sameFilesystem(a, b string) bool {
+	var statA, statB unix.Stat_t
+	if unix.Stat(a, &statA) != nil || unix.Stat(b, &statB) != nil {
+		return false
+	}
+	return statA.Dev == statB.Dev
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// freeSpace returns the bytes available to this user on the volume holding path
+func freeSpace(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
+
+// sameFilesystem reports whether two paths are on the same volume, so moving
+// between them is a rename rather than a copy
+func sameFilesystem(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return strings.EqualFold(filepath.VolumeName(absA), filepath.VolumeName(absB))
+}
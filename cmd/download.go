@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -8,31 +10,79 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/appids"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
 	"github.com/davidroman0O/steam-workshop-downloader/pkg/scraper"
 	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/webapi"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // downloadCmd represents the download command
 var downloadCmd = &cobra.Command{
-	Use:   "download [URL or ID]",
+	Use:   "download [URL or ID] | --from-json collection.json | --from-file items.txt",
 	Short: "Download Steam Workshop items",
 	Long: `Download Steam Workshop items using various input formats:
 
 Supported formats:
 - Workshop URL: https://steamcommunity.com/sharedfiles/filedetails/?id=123456789
-- Direct ID: 123456789 (requires --app-id)
+- Direct ID: 123456789 (App ID from --app-id, or looked up with the Steam Web API)
 - App ID + Workshop ID: 431960 123456789
+- Collection JSON: --from-json collection.json containing either
+  {"appid": 431960, "items": [123456789, ...]} or
+  [{"appid": 431960, "workshopid": 123456789}, ...]
+- Item list: --from-file items.txt with one "<app id> <workshop id>" per line
 
 Examples:
   workshop download https://steamcommunity.com/sharedfiles/filedetails/?id=2503622437
   workshop download 2503622437 --app-id 108600
-  workshop download 108600 2503622437`,
-	Args: cobra.MinimumNArgs(1),
+  workshop download 108600 2503622437
+  workshop download --from-json collection.json --failed-out failed.txt
+  workshop download --from-json collection.json --limit 3
+  workshop download --from-file failed.txt`,
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return downloadWorkshopItem(args)
+		if err := lockSteamCMD(); err != nil {
+			return err
+		}
+
+		fromJSON, fromFile := viper.GetString("from_json"), viper.GetString("from_file")
+		if fromJSON != "" && fromFile != "" {
+			return fmt.Errorf("--from-json and --from-file cannot be combined")
+		}
+		if (fromJSON != "" || fromFile != "") && len(args) > 0 {
+			return fmt.Errorf("--from-json and --from-file cannot be combined with positional arguments")
+		}
+		if limit := viper.GetInt("limit"); limit < 0 {
+			return fmt.Errorf("invalid --limit %d: must be 0 (no limit) or a positive number", limit)
+		} else if limit > 0 && fromJSON == "" && fromFile == "" {
+			return fmt.Errorf("--limit requires --from-json or --from-file")
+		}
+		if viper.GetString("output_manifest") != "" && len(outputDirs()) == 0 {
+			return fmt.Errorf("--output-manifest requires --output")
+		}
+
+		withDeps := viper.GetBool("with_deps")
+		if withDeps && (fromJSON != "" || fromFile != "") {
+			return fmt.Errorf("--with-deps applies to a single item, not --from-json or --from-file")
+		}
+
+		run := func() error { return downloadWorkshopItem(args) }
+		switch {
+		case fromJSON != "":
+			run = func() error { return downloadFromJSON(fromJSON) }
+		case fromFile != "":
+			run = func() error { return downloadFromFile(fromFile) }
+		case len(args) == 0:
+			return fmt.Errorf("requires a workshop URL or ID, --from-json or --from-file")
+		case withDeps:
+			run = func() error { return downloadWithDependencies(args) }
+		}
+		return withManifestOut(func() error { return withOutputManifest(run) })
 	},
 }
 
@@ -41,61 +91,367 @@ func init() {
 
 	downloadCmd.Flags().StringP("app-id", "a", "", "Steam App ID (required if not providing URL)")
 	downloadCmd.Flags().BoolP("extract", "e", true, "Extract downloaded files to output directory")
-	downloadCmd.Flags().StringP("output", "o", "", "Output directory (default: configured download directory)")
+	downloadCmd.Flags().StringSliceP("output", "o", nil, "Output directory; repeat or comma-separate to copy to several (default: configured download directory)")
+	downloadCmd.Flags().Bool("install-to-game", false, "Also copy the item into the workshop content directory of the installed game, where the Steam client loads mods from")
+	downloadCmd.Flags().Bool("content-only", false, "Only download into SteamCMD's content directory and print its path; no output is copied or extracted")
+	downloadCmd.Flags().String("game-dir", "", "Steam game install to take the App ID of bare workshop IDs from (default: --output, if it is one)")
 	downloadCmd.Flags().BoolP("debug", "d", false, "Show debug information including SteamCMD command")
 	downloadCmd.Flags().StringP("username", "u", "", "Steam username to use cached credentials (use after 'workshop login')")
+	downloadCmd.Flags().Bool("verify-app-id", false, "Look the App ID up on the Steam Store before downloading and warn when it doesn't exist (skipped without network)")
+	downloadCmd.Flags().Bool("force-anonymous", false, "Download anonymously even if the app isn't known to allow it")
 	downloadCmd.Flags().BoolP("force", "f", false, "Force re-download even if item already exists")
+	downloadCmd.Flags().String("confirm-large", "", "Ask before downloading an item the Steam Web API reports as larger than this size, e.g. 5GB (interactive terminals only)")
+	downloadCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation, e.g. with --confirm-large")
+	downloadCmd.Flags().Bool("fail-on-empty", false, "Treat a download that left no content on disk as a failure instead of warning")
+	downloadCmd.Flags().Bool("retry-on-empty", false, "Retry a download SteamCMD reports as successful but that left no content on disk, before warning or failing")
+	downloadCmd.Flags().String("extract-file", "", "Only copy files matching this glob (e.g. '*.pak') to the output directory")
+	downloadCmd.Flags().Bool("prune-cache", false, "With --extract-file, delete non-matching files from the download cache")
+	downloadCmd.Flags().Bool("auto-unpack", false, "Extract zip, tar, 7z and rar archives found in the output (7z and rar need 7z or unrar on PATH)")
+	downloadCmd.Flags().Bool("keep-archives", false, "With --auto-unpack, keep the archives after extracting them")
+	downloadCmd.Flags().Bool("trim-output", false, "Remove source files, VCS folders, previews and editor junk from the output after copying")
+	downloadCmd.Flags().StringSlice("trim-pattern", nil, "With --trim-output, also remove entries matching this glob (repeatable; a trailing / matches folders only)")
+	downloadCmd.Flags().Bool("output-flat-per-game", false, "Merge the item's files into one shared directory per game (<output>/app_<appid>) instead of a folder per item, for games that load all mods from one folder")
+	downloadCmd.Flags().String("flat-collision", flatCollisionSkip, "With --output-flat-per-game, what to do with files another item already wrote: "+strings.Join(flatCollisionStrategies, ", "))
+	downloadCmd.Flags().String("output-manifest", "", "Write the path and size of every file written to --output to this file")
+	downloadCmd.Flags().Bool("output-manifest-hashes", false, "With --output-manifest, also record the SHA-256 of each file")
+	downloadCmd.Flags().String("only-missing-files", "", "Repair an output: copy only the files an --output-manifest file lists that are missing from --output or differ in size (or SHA-256, when recorded)")
+	downloadCmd.Flags().Bool("show-contents", false, "Print a summary of the downloaded files")
+	downloadCmd.Flags().Int("top-files", 5, "With --show-contents, how many of the largest files to list")
+	downloadCmd.Flags().Int("tree-depth", 0, "With --show-contents, also print the directory tree down to this depth")
+	downloadCmd.Flags().Bool("if-newer", false, "Only download items not downloaded yet or updated on the workshop since the installed version")
+	downloadCmd.Flags().Bool("check-update", false, "Compare the local size with the size reported by Steam instead of downloading")
+	downloadCmd.Flags().Bool("latest-link", false, "Copy each download to a versioned directory and point a stable 'latest' link at it")
+	downloadCmd.Flags().String("staging-dir", "", "Extract into a per-run directory under this path instead of --output")
+	downloadCmd.Flags().Bool("promote", false, "With --staging-dir, move staged items to --output once they're extracted")
+	downloadCmd.Flags().Bool("durable", false, "fsync copied output files before renaming them into place")
+	downloadCmd.Flags().String("legacy-depot", "", "Depot ID to fall back to with download_depot for legacy items that fail the workshop download")
+	downloadCmd.Flags().Bool("with-deps", false, "Also download the items listed under \"Required items\" on the item's page, recursively, before the item")
+	downloadCmd.Flags().String("from-json", "", "Download every item of a collection exported as JSON")
+	downloadCmd.Flags().String("from-file", "", "Download every item of a text file with one \"<app id> <workshop id>\" per line, e.g. written by --failed-out")
+	downloadCmd.Flags().Int("limit", 0, "With --from-json or --from-file, only download the first N items (after removing duplicates), e.g. to try a large collection first (0, the default, downloads all)")
+	downloadCmd.Flags().Bool("case-safe", false, "Write files whose paths differ only by case under numbered names (name_2.ext) so they don't overwrite each other on case-insensitive file systems")
+	downloadCmd.Flags().String("dir-mode", "", "Octal permissions for created output directories (e.g. 0775)")
+	downloadCmd.Flags().String("file-mode", "", "Octal permissions for copied output files, overriding source modes (e.g. 0664)")
+
+	// Hidden: only meant for testing tools that wrap this one
+	downloadCmd.Flags().String("simulate", "", "Fake an outcome without running SteamCMD: "+simulationModes())
+	downloadCmd.Flags().MarkHidden("simulate")
+
+	bindFlag("app_id", downloadCmd.Flags().Lookup("app-id"))
+	bindFlag("extract", downloadCmd.Flags().Lookup("extract"))
+	bindFlag("output", downloadCmd.Flags().Lookup("output"))
+	bindFlag("install_to_game", downloadCmd.Flags().Lookup("install-to-game"))
+	bindFlag("content_only", downloadCmd.Flags().Lookup("content-only"))
+	bindFlag("game_dir", downloadCmd.Flags().Lookup("game-dir"))
+	bindFlag("debug", downloadCmd.Flags().Lookup("debug"))
+	bindFlag("username", downloadCmd.Flags().Lookup("username"))
+	bindFlag("verify_app_id", downloadCmd.Flags().Lookup("verify-app-id"))
+	bindFlag("force_anonymous", downloadCmd.Flags().Lookup("force-anonymous"))
+	bindFlag("force_download", downloadCmd.Flags().Lookup("force"))
+	bindFlag("confirm_large", downloadCmd.Flags().Lookup("confirm-large"))
+	bindFlag("yes", downloadCmd.Flags().Lookup("yes"))
+	bindFlag("fail_on_empty", downloadCmd.Flags().Lookup("fail-on-empty"))
+	bindFlag("retry_on_empty", downloadCmd.Flags().Lookup("retry-on-empty"))
+	bindFlag("extract_file", downloadCmd.Flags().Lookup("extract-file"))
+	bindFlag("prune_cache", downloadCmd.Flags().Lookup("prune-cache"))
+	bindFlag("auto_unpack", downloadCmd.Flags().Lookup("auto-unpack"))
+	bindFlag("keep_archives", downloadCmd.Flags().Lookup("keep-archives"))
+	bindFlag("trim_output", downloadCmd.Flags().Lookup("trim-output"))
+	bindFlag("trim_patterns", downloadCmd.Flags().Lookup("trim-pattern"))
+	bindFlag("output_flat_per_game", downloadCmd.Flags().Lookup("output-flat-per-game"))
+	bindFlag("flat_collision", downloadCmd.Flags().Lookup("flat-collision"))
+	bindFlag("output_manifest", downloadCmd.Flags().Lookup("output-manifest"))
+	bindFlag("output_manifest_hashes", downloadCmd.Flags().Lookup("output-manifest-hashes"))
+	bindFlag("only_missing_files", downloadCmd.Flags().Lookup("only-missing-files"))
+	bindFlag("show_contents", downloadCmd.Flags().Lookup("show-contents"))
+	bindFlag("top_files", downloadCmd.Flags().Lookup("top-files"))
+	bindFlag("tree_depth", downloadCmd.Flags().Lookup("tree-depth"))
+	bindFlag("if_newer", downloadCmd.Flags().Lookup("if-newer"))
+	bindFlag("check_update", downloadCmd.Flags().Lookup("check-update"))
+	bindFlag("latest_link", downloadCmd.Flags().Lookup("latest-link"))
+	bindFlag("staging_dir", downloadCmd.Flags().Lookup("staging-dir"))
+	bindFlag("promote", downloadCmd.Flags().Lookup("promote"))
+	bindFlag("durable", downloadCmd.Flags().Lookup("durable"))
+	bindFlag("legacy_depot", downloadCmd.Flags().Lookup("legacy-depot"))
+	bindFlag("with_deps", downloadCmd.Flags().Lookup("with-deps"))
+	bindFlag("from_json", downloadCmd.Flags().Lookup("from-json"))
+	bindFlag("from_file", downloadCmd.Flags().Lookup("from-file"))
+	bindFlag("limit", downloadCmd.Flags().Lookup("limit"))
+	bindFlag("simulate", downloadCmd.Flags().Lookup("simulate"))
+	bindFlag("case_safe", downloadCmd.Flags().Lookup("case-safe"))
+	bindFlag("dir_mode", downloadCmd.Flags().Lookup("dir-mode"))
+	bindFlag("file_mode", downloadCmd.Flags().Lookup("file-mode"))
+}
+
+// Download result statuses
+const (
+	statusDownloaded = "downloaded"
+	statusSkipped    = "skipped"
+	statusFailed     = "failed"
+)
 
-	viper.BindPFlag("app_id", downloadCmd.Flags().Lookup("app-id"))
-	viper.BindPFlag("extract", downloadCmd.Flags().Lookup("extract"))
-	viper.BindPFlag("output", downloadCmd.Flags().Lookup("output"))
-	viper.BindPFlag("debug", downloadCmd.Flags().Lookup("debug"))
-	viper.BindPFlag("username", downloadCmd.Flags().Lookup("username"))
-	viper.BindPFlag("force_download", downloadCmd.Flags().Lookup("force"))
+// downloadResult describes the outcome of downloading one workshop item
+type downloadResult struct {
+	AppID      string `json:"app_id"`
+	WorkshopID string `json:"workshop_id"`
+	Status     string `json:"status"`
+	Title      string `json:"title,omitempty"` // From the workshop page or Web API, when it was looked up
+	Game       string `json:"game,omitempty"`
+	Path       string `json:"path,omitempty"`
+	SizeBytes  int64  `json:"size_bytes"`
+	Update     string `json:"update,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"`
+
+	Account  string         `json:"account,omitempty"` // Login SteamCMD used: a username or "anonymous"
+	Outputs  []outputResult `json:"outputs,omitempty"`
+	GamePath string         `json:"game_path,omitempty"` // Where --install-to-game put the item
+
+	Timings []phaseTiming `json:"timings,omitempty"` // Time spent in each phase, with --timings
 }
 
+// outputResult is the outcome of copying an item to one --output directory
+type outputResult struct {
+	Dir   string `json:"dir"`
+	Error string `json:"error,omitempty"`
+}
+
+// downloadWorkshopItem downloads a single item. In JSON mode its result
+// goes to stdout as one JSON object, like each item of a batch.
 func downloadWorkshopItem(args []string) error {
+	result, err := downloadItem(humanOutput(), args)
+	if jsonOutput() {
+		if writeErr := newJSONLinesWriter(os.Stdout).Write(result); writeErr != nil {
+			return fmt.Errorf("failed to write result: %w", writeErr)
+		}
+	}
+	return err
+}
+
+// downloadItem downloads a single workshop item and reports the outcome.
+// The returned result is never nil; on failure its Status is statusFailed.
+func downloadItem(w io.Writer, args []string) (*downloadResult, error) {
+	result := &downloadResult{Status: statusFailed}
+	start := time.Now()
+	err := downloadItemInto(w, result, args)
+	if timingsEnabled() {
+		printTimings(w, result.Timings, time.Since(start))
+	} else {
+		result.Timings = nil
+	}
+	if err == nil && result.Update == "" {
+		recordLockedItem(w, result)
+	}
+	if err != nil {
+		result.Status = statusFailed
+		result.Error = err.Error()
+		result.ErrorCode = errorCode(err)
+		if result.WorkshopID != "" {
+			err = &itemError{AppID: result.AppID, WorkshopID: result.WorkshopID, Err: err}
+		}
+	}
+	logResult(result, time.Since(start))
+	return result, err
+}
+
+func downloadItemInto(w io.Writer, result *downloadResult, args []string) error {
 	// Parse input to extract app ID and workshop ID
-	appID, workshopID, itemInfo, err := parseDownloadInput(args)
+	lookupStart := time.Now()
+	appID, workshopID, itemInfo, err := parseDownloadInput(w, args)
+	result.timePhase(phaseLookup, lookupStart)
 	if err != nil {
 		return fmt.Errorf("invalid input: %w", err)
 	}
+	result.AppID = appID
+	result.WorkshopID = workshopID
+	if itemInfo != nil {
+		result.Title = itemInfo.Title
+		result.Game = itemInfo.GameName
+	}
+
+	// Output settings don't matter when the content stays where SteamCMD put it
+	contentOnly := viper.GetBool("content_only")
+
+	// Validate file selection before spending time on the download
+	extractFile := viper.GetString("extract_file")
+	if extractFile != "" && !contentOnly {
+		if _, err := filepath.Match(extractFile, ""); err != nil {
+			return fmt.Errorf("invalid --extract-file pattern %q: %w", extractFile, err)
+		}
+		if len(outputDirs()) == 0 {
+			return fmt.Errorf("--extract-file requires --output")
+		}
+	}
+
+	// Archives are only unpacked in the output, never in SteamCMD's content directory
+	if viper.GetBool("auto_unpack") && len(outputDirs()) == 0 && !contentOnly {
+		return fmt.Errorf("--auto-unpack requires --output")
+	}
+
+	// Staged items are promoted into the output directory
+	if viper.GetString("staging_dir") != "" && !contentOnly {
+		switch len(outputDirs()) {
+		case 0:
+			return fmt.Errorf("--staging-dir requires --output")
+		case 1:
+		default:
+			return fmt.Errorf("--staging-dir takes a single --output")
+		}
+	}
+	// A shared game directory has no per-item folder to stage, version, unpack or trim
+	if viper.GetBool("output_flat_per_game") && !contentOnly {
+		if len(outputDirs()) == 0 {
+			return fmt.Errorf("--output-flat-per-game requires --output")
+		}
+		for flag, set := range map[string]bool{
+			"--staging-dir": viper.GetString("staging_dir") != "",
+			"--latest-link": viper.GetBool("latest_link"),
+			"--auto-unpack": viper.GetBool("auto_unpack"),
+			"--trim-output": viper.GetBool("trim_output"),
+			"--case-safe":   viper.GetBool("case_safe"),
+		} {
+			if set {
+				return fmt.Errorf("--output-flat-per-game cannot be combined with %s", flag)
+			}
+		}
+		if err := validateFlatCollision(viper.GetString("flat_collision")); err != nil {
+			return err
+		}
+	}
+	// Repairs compare the plain per-item layout the manifest was written from
+	if viper.GetString("only_missing_files") != "" && !contentOnly {
+		if len(outputDirs()) == 0 {
+			return fmt.Errorf("--only-missing-files requires --output")
+		}
+		for flag, set := range map[string]bool{
+			"--staging-dir":          viper.GetString("staging_dir") != "",
+			"--latest-link":          viper.GetBool("latest_link"),
+			"--auto-unpack":          viper.GetBool("auto_unpack"),
+			"--trim-output":          viper.GetBool("trim_output"),
+			"--extract-file":         extractFile != "",
+			"--output-flat-per-game": viper.GetBool("output_flat_per_game"),
+			"--case-safe":            viper.GetBool("case_safe"),
+		} {
+			if set {
+				return fmt.Errorf("--only-missing-files cannot be combined with %s", flag)
+			}
+		}
+		if _, err := repairManifest(); err != nil {
+			return err
+		}
+	}
+	if viper.GetBool("install_to_game") && contentOnly {
+		return fmt.Errorf("--install-to-game cannot be combined with --content-only")
+	}
+	if viper.GetBool("promote") && viper.GetString("staging_dir") == "" && !contentOnly {
+		return fmt.Errorf("--promote requires --staging-dir")
+	}
+
+	// Fault injection for testing wrappers: skip SteamCMD entirely
+	if mode := viper.GetString("simulate"); mode != "" {
+		return simulateDownload(w, result, mode)
+	}
+
+	// Validate output permissions
+	copyOpts, err := copyOptionsFromConfig(w)
+	if err != nil {
+		return err
+	}
 
 	// Show what we're downloading
 	if itemInfo != nil && itemInfo.Title != "" {
-		fmt.Printf("Found: %s\n", itemInfo.Title)
+		fmt.Fprintf(w, "Found: %s\n", itemInfo.Title)
 		if itemInfo.GameName != "" {
-			fmt.Printf("Game: %s\n", itemInfo.GameName)
+			fmt.Fprintf(w, "Game: %s\n", itemInfo.GameName)
 		}
 	}
 
 	// Create SteamCMD client
-	steamcmdDir := viper.GetString("steamcmd_dir")
-	client, err := steamcmd.NewClient(steamcmdDir)
+	client, err := newSteamCMDClient(w)
 	if err != nil {
 		return fmt.Errorf("failed to create SteamCMD client: %w", err)
 	}
 
-	fmt.Printf("Downloading workshop item %s for app %s...\n", workshopID, appID)
+	// Only report whether an update is available, without running SteamCMD
+	if viper.GetBool("check_update") {
+		return checkForUpdate(w, client, result)
+	}
+
+	// An App ID Steam gave us doesn't need checking
+	if itemInfo == nil || itemInfo.AppID != appID {
+		verifyAppID(w, appID)
+	}
+
+	// Anonymous logins only work for some apps
+	if err := checkAnonymous(w, appID); err != nil {
+		return err
+	}
+
+	// Don't download what can't be installed into the game
+	if viper.GetBool("install_to_game") {
+		if _, err := gameInstallDir(appID, workshopID); err != nil {
+			return fmt.Errorf("cannot install into the game: %w", err)
+		}
+	}
+
+	// Only spend a SteamCMD run on items that changed since the installed version
+	// A repair needs the content even when SteamCMD already has it
+	force := viper.GetBool("force_download") || viper.GetString("only_missing_files") != ""
+	update := false
+	if viper.GetBool("if_newer") && !force {
+		if !checkIfNewer(w, client, result) {
+			return nil
+		}
+		update = true
+	}
+
+	fmt.Fprintf(w, "Downloading workshop item %s for app %s...\n", workshopID, appID)
+	if username := viper.GetString("username"); username != "" {
+		fmt.Fprintf(w, "Using the cached credentials of %s\n", username)
+	}
 
 	// Check if item already exists
-	force := viper.GetBool("force_download")
 	exists, existingPath, err := client.CheckWorkshopItemExists(appID, workshopID)
 	if err != nil {
-		fmt.Printf("Warning: Failed to check if item exists: %v\n", err)
+		fmt.Fprintf(w, "Warning: Failed to check if item exists: %v\n", err)
+	} else if exists && update {
+		fmt.Fprintf(w, "Updating the copy at %s...\n", existingPath)
 	} else if exists && !force {
-		fmt.Printf("✅ Workshop item already exists at: %s\n", existingPath)
+		fmt.Fprintf(w, "%s Workshop item already exists at: %s\n", iconOK, existingPath)
 
 		// Calculate size if possible
 		dirSize := getDirSize(existingPath)
 		if dirSize > 0 {
-			fmt.Printf("📁 Directory size: %s\n", formatBytes(dirSize))
+			fmt.Fprintf(w, "%s Directory size: %s\n", iconDir, formatBytes(dirSize))
+		}
+
+		if viper.GetBool("show_contents") {
+			printContents(w, existingPath)
+		}
+
+		result.Status = statusSkipped
+		result.Path = existingPath
+		result.SizeBytes = dirSize
+		if contentOnly {
+			fmt.Fprintln(w, existingPath)
+			return nil
 		}
 
-		fmt.Println("\n💡 Use --force flag to re-download, or use --output to extract to a different location.")
+		fmt.Fprintf(w, "\n%s Use --force flag to re-download, or use --output to extract to a different location.\n", iconTip)
 		return nil
 	} else if exists && force {
-		fmt.Printf("⚠️  Workshop item exists at %s but --force flag used, re-downloading...\n", existingPath)
+		fmt.Fprintf(w, "%s Workshop item exists at %s but --force flag used, re-downloading...\n", iconWarn, existingPath)
+	}
+
+	// Don't start a huge download by accident
+	proceed, err := confirmLargeDownload(w, workshopID)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		fmt.Fprintln(w, "Download cancelled.")
+		result.Status = statusSkipped
+		return nil
 	}
 
 	// Download the workshop item
@@ -104,23 +460,72 @@ func downloadWorkshopItem(args []string) error {
 
 	// Show debug info if requested
 	if debug {
-		fmt.Printf("Debug: SteamCMD command would be: %s\n", client.GetDebugCommand(appID, workshopID))
-		fmt.Println("Debug: You can run this command manually to test SteamCMD directly")
-		fmt.Println()
+		fmt.Fprintf(w, "Debug: SteamCMD command would be: %s\n", client.GetDebugCommand(appID, workshopID))
+		fmt.Fprintln(w, "Debug: You can run this command manually to test SteamCMD directly")
+		fmt.Fprintln(w)
+	}
+
+	// Parallel batch workers take turns running SteamCMD
+	fmt.Fprintln(w, "Attempting download...")
+	steamcmdRuns.Lock()
+
+	// Time from launching SteamCMD to its first line of output, for --timings
+	downloadStart := time.Now()
+	onOutput := client.OnOutput
+	var firstOutput sync.Once
+	client.OnOutput = func(line string) {
+		firstOutput.Do(func() { result.timePhase(phaseSteamCMDWait, downloadStart) })
+		if onOutput != nil {
+			onOutput(line)
+		}
 	}
+	defer func() { client.OnOutput = onOutput }()
 
-	fmt.Println("Attempting download...")
 	item, err = client.DownloadWorkshopItem(appID, workshopID, viper.GetString("username"))
 
+	// Old items distributed as depots fail the normal path with a known signature
+	if err != nil && steamcmd.IsLegacyDepotFailure(err.Error()) {
+		depotID := viper.GetString("legacy_depot")
+		if depotID == "" {
+			fmt.Fprintf(w, "%s This may be a legacy item distributed as a depot. If you know its depot ID, retry with --legacy-depot <depotid>\n", iconTip)
+		} else {
+			fmt.Fprintf(w, "Falling back to download_depot for depot %s...\n", depotID)
+			item, err = client.DownloadDepot(appID, depotID, viper.GetString("username"))
+			if err == nil {
+				item.WorkshopID = workshopID
+				item.SizeBytes = getDirSize(item.PathToFile)
+			}
+		}
+	}
+	steamcmdRuns.Unlock()
+
+	// With cached credentials for several accounts, confirm which one was used
+	if item != nil && item.Account != "" {
+		result.Account = item.Account
+		if item.Account == steamcmd.AccountAnonymous {
+			fmt.Fprintln(w, "Logged in anonymously")
+		} else {
+			fmt.Fprintf(w, "Logged in as %s\n", item.Account)
+		}
+	}
+
+	result.timePhase(phaseDownload, downloadStart)
+
 	if err != nil {
+		printAttempts(w, err)
+
+		if errors.Is(err, steamcmd.ErrNotOwned) {
+			printNotOwnedHelp(w, appID)
+			return fmt.Errorf("download failed: %w", err)
+		}
+
 		// Check if this might be an authentication issue
-		if strings.Contains(err.Error(), "No subscription") ||
-			strings.Contains(err.Error(), "login") ||
+		if strings.Contains(err.Error(), "login") ||
 			strings.Contains(err.Error(), "authentication") {
-			fmt.Println()
-			fmt.Println("❌ Download failed - this might require Steam authentication.")
-			fmt.Println("💡 Try logging in first with: workshop login")
-			fmt.Println("   Then try downloading again.")
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "%s Download failed - this might require Steam authentication.\n", iconErr)
+			fmt.Fprintf(w, "%s Try logging in first with: workshop login\n", iconTip)
+			fmt.Fprintln(w, "   Then try downloading again.")
 		}
 		return fmt.Errorf("download failed: %w", err)
 	}
@@ -129,23 +534,142 @@ func downloadWorkshopItem(args []string) error {
 		return fmt.Errorf("download unsuccessful: %s", item.ErrorMsg)
 	}
 
-	fmt.Printf("Successfully downloaded to: %s\n", item.PathToFile)
-	fmt.Printf("Size: %s\n", formatBytes(item.SizeBytes))
+	// SteamCMD reports success for empty or removed items too, so trust the disk
+	if reason := steamcmd.EmptyContent(item.PathToFile); reason != "" {
+		err := fmt.Errorf("%w: %s; the item may be empty or removed from the workshop", steamcmd.ErrEmptyDownload, reason)
+		if viper.GetBool("fail_on_empty") {
+			return err
+		}
+		fmt.Fprintf(w, "%s %v\n", iconWarn, err)
+	} else if item.SizeBytes == 0 {
+		item.SizeBytes = getDirSize(item.PathToFile)
+	}
+
+	fmt.Fprintf(w, "Successfully downloaded to: %s\n", item.PathToFile)
+	fmt.Fprintf(w, "Size: %s\n", formatBytes(item.SizeBytes))
+	result.Status = statusDownloaded
+	result.Path = item.PathToFile
+	result.SizeBytes = item.SizeBytes
 
-	// Handle extraction/copying if requested
-	outputDir := viper.GetString("output")
-	extract := viper.GetBool("extract")
+	if viper.GetBool("show_contents") {
+		printContents(w, item.PathToFile)
+	}
+
+	// Leave the rest to the caller, with the path on a line of its own
+	if contentOnly {
+		fmt.Fprintln(w, item.PathToFile)
+		return nil
+	}
 
-	if extract && outputDir != "" {
-		if err := handleOutput(item, outputDir, appID, workshopID); err != nil {
-			fmt.Printf("Warning: Failed to handle output: %v\n", err)
+	outputStart := time.Now()
+	defer result.timePhase(phaseOutput, outputStart)
+
+	// Handle extraction/copying if requested; each destination succeeds or fails on its own
+	outputs := outputDirs()
+	if !viper.GetBool("extract") {
+		outputs = nil
+	}
+
+	for _, outputDir := range outputs {
+		output := outputResult{Dir: outputDir}
+		handle := handleOutput
+		if viper.GetBool("output_flat_per_game") {
+			handle = handleFlatOutput
 		}
+		if err := handle(w, item, outputDir, appID, workshopID, copyOpts); err != nil {
+			fmt.Fprintf(w, "Warning: Failed to handle output %s: %v\n", outputDir, err)
+			output.Error = err.Error()
+		}
+		result.Outputs = append(result.Outputs, output)
+	}
+
+	if len(outputs) > 1 {
+		failed := failedOutputs(result.Outputs)
+		fmt.Fprintf(w, "Copied to %d of %d output directories\n", len(outputs)-failed, len(outputs))
+	}
+
+	if viper.GetBool("install_to_game") {
+		gamePath, err := installToGame(w, item, appID, workshopID, copyOpts)
+		if err != nil {
+			return fmt.Errorf("failed to install into the game: %w", err)
+		}
+		result.GamePath = gamePath
+		fmt.Fprintf(w, "%s Installed into the game: %s\n", iconOK, gamePath)
 	}
 
 	return nil
 }
 
-func parseDownloadInput(args []string) (appID, workshopID string, itemInfo *scraper.WorkshopInfo, err error) {
+// steamcmdRuns serializes the SteamCMD downloads of parallel batch workers.
+// They share one steamcmd_dir, and SteamCMD processes running in the same
+// directory corrupt each other's download cache and logs. Lookups and
+// output copies still run in parallel.
+var steamcmdRuns sync.Mutex
+
+// outputDirs returns the --output directories. Flags may be repeated or
+// comma-separated; a single string from the config or environment is split
+// on commas too.
+func outputDirs() []string {
+	values := viper.GetStringSlice("output")
+	if s, ok := viper.Get("output").(string); ok {
+		values = []string{s}
+	}
+
+	var dirs []string
+	for _, value := range values {
+		for _, dir := range strings.Split(value, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// failedOutputs counts the output directories the item couldn't be copied to
+func failedOutputs(outputs []outputResult) int {
+	var failed int
+	for _, output := range outputs {
+		if output.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// printAttempts lists the error of every failed attempt in verbose mode
+func printAttempts(w io.Writer, err error) {
+	var retryErr *steamcmd.RetryError
+	if !viper.GetBool("verbose") || !errors.As(err, &retryErr) {
+		return
+	}
+
+	fmt.Fprintln(w, "Failed attempts:")
+	for i, attempt := range retryErr.Attempts {
+		// The first line is enough; SteamCMD's output follows on the next ones
+		msg, _, _ := strings.Cut(attempt.Error(), "\n")
+		fmt.Fprintf(w, "  %d. [%v] %s\n", i+1, attempt.Kind, msg)
+	}
+}
+
+// printNotOwnedHelp explains a license failure, which depends on whether
+// SteamCMD was logged in
+func printNotOwnedHelp(w io.Writer, appID string) {
+	fmt.Fprintln(w)
+	username := viper.GetString("username")
+	if username == "" {
+		fmt.Fprintf(w, "%s Steam requires owning app %s to download its workshop items.\n", iconErr, appID)
+		fmt.Fprintf(w, "%s Log in with an account that owns it (workshop login), then pass --username.\n", iconTip)
+		return
+	}
+
+	fmt.Fprintf(w, "%s Steam reports that %s doesn't own app %s.\n", iconErr, username, appID)
+	fmt.Fprintln(w, "   SteamCMD only uses licenses the account owns itself: games borrowed through")
+	fmt.Fprintln(w, "   Steam Family Sharing don't count. Log in with the account that owns the game,")
+	fmt.Fprintln(w, "   or subscribe to the item in the Steam client and copy it from there.")
+}
+
+func parseDownloadInput(w io.Writer, args []string) (appID, workshopID string, itemInfo *scraper.WorkshopInfo, err error) {
 	if len(args) == 0 {
 		return "", "", nil, fmt.Errorf("no input provided")
 	}
@@ -177,14 +701,44 @@ func parseDownloadInput(args []string) (appID, workshopID string, itemInfo *scra
 			return "", "", nil, fmt.Errorf("unsupported URL host: %s", parsedURL.Host)
 		}
 
-		fmt.Println("Extracting information from workshop page...")
+		// An App ID found by an earlier scrape saves fetching the page again
+		if workshopID, err := parseWorkshopURL(input); err == nil {
+			info, ok := cachedItemInfo(w, workshopID, func(info *scraper.WorkshopInfo) {
+				if scraped, _ := scraper.ScrapeWorkshopPage(input); scraped != nil {
+					info.Title, info.GameName = scraped.Title, scraped.GameName
+				}
+			})
+			if ok {
+				return info.AppID, workshopID, info, nil
+			}
+		}
+
+		fmt.Fprintln(w, "Extracting information from workshop page...")
 
 		// Use scraper to get App ID and other info
 		itemInfo, err := scraper.ScrapeWorkshopPage(input)
 		if err != nil {
-			return "", "", nil, fmt.Errorf("failed to scrape workshop page: %w", err)
+			// Degrade to the workshop ID from the URL and an App ID supplied by the user
+			workshopID, urlErr := parseWorkshopURL(input)
+			if urlErr != nil {
+				return "", "", nil, fmt.Errorf("failed to scrape workshop page: %w", err)
+			}
+
+			fmt.Fprintf(w, "%s Could not determine the App ID from the workshop page: %v\n", iconWarn, err)
+			appID, err := fallbackAppID(w, workshopID)
+			if err != nil {
+				return "", "", nil, err
+			}
+
+			// A page without an App ID still named the item
+			if itemInfo != nil && itemInfo.Title != "" {
+				itemInfo.AppID = appID
+				rememberItem(w, itemInfo)
+			}
+			return appID, workshopID, itemInfo, nil
 		}
 
+		rememberItem(w, itemInfo)
 		return itemInfo.AppID, itemInfo.WorkshopID, itemInfo, nil
 	}
 
@@ -193,18 +747,191 @@ func parseDownloadInput(args []string) (appID, workshopID string, itemInfo *scra
 		workshopID = input
 		appID = viper.GetString("app_id")
 
-		if appID == "" {
-			return "", "", nil, fmt.Errorf("app ID is required when providing only workshop ID. Use --app-id flag or provide both app ID and workshop ID")
+		if appID != "" {
+			return appID, workshopID, nil, nil
+		}
+		if appID, ok := gameDirAppID(w); ok {
+			return appID, workshopID, nil, nil
 		}
 
-		return appID, workshopID, nil, nil
+		// Resolve the App ID from the cache or the Web API, without scraping the workshop page
+		info, ok := cachedItemInfo(w, workshopID, func(info *scraper.WorkshopInfo) {
+			if details, err := webapi.GetPublishedFileDetails(workshopID); err == nil {
+				info.Title = details.Title
+			}
+		})
+		if ok {
+			return info.AppID, workshopID, info, nil
+		}
+
+		fmt.Fprintln(w, "Looking up the App ID with the Steam Web API...")
+		details, err := webapi.GetPublishedFileDetails(workshopID)
+		if err != nil || details.AppID == "" {
+			if err == nil {
+				err = fmt.Errorf("no App ID reported for item %s", workshopID)
+			}
+			fmt.Fprintf(w, "%s Could not determine the App ID from the Steam Web API: %v\n", iconWarn, err)
+			appID, err := fallbackAppID(w, workshopID)
+			if err != nil {
+				return "", "", nil, err
+			}
+			return appID, workshopID, nil, nil
+		}
+
+		itemInfo = &scraper.WorkshopInfo{AppID: details.AppID, WorkshopID: workshopID, Title: details.Title}
+		rememberItem(w, itemInfo)
+		return details.AppID, workshopID, itemInfo, nil
 	}
 
 	return "", "", nil, fmt.Errorf("invalid input format")
 }
 
+// fallbackAppID resolves the App ID of a workshop item when scraping failed,
+// from --app-id, the App ID cache, or by asking the user. The answer is cached
+// so later runs don't have to ask again.
+func fallbackAppID(w io.Writer, workshopID string) (string, error) {
+	store, err := openAppIDStore()
+	if err != nil {
+		fmt.Fprintf(w, "Warning: %v\n", err)
+	}
+
+	appID := viper.GetString("app_id")
+	if appID == "" && store != nil {
+		if cached, ok := store.Get(workshopID); ok {
+			fmt.Fprintf(w, "Using cached App ID %s for workshop item %s\n", cached.AppID, workshopID)
+			return cached.AppID, nil
+		}
+	}
+
+	if appID == "" {
+		if appID, err = promptAppID(workshopID); err != nil {
+			return "", err
+		}
+	}
+
+	if err := ValidateAppID(appID); err != nil {
+		return "", err
+	}
+
+	if store != nil {
+		// The lookup that would have found the title just failed
+		store.Set(workshopID, appids.Entry{AppID: appID, TitleFetched: true})
+		if err := store.Save(); err != nil {
+			fmt.Fprintf(w, "Warning: %v\n", err)
+		}
+	}
+
+	return appID, nil
+}
+
+// appIDPrompts serializes the App ID prompts of parallel batch workers, so
+// each question and the answer read from stdin belong to the same item
+var appIDPrompts sync.Mutex
+
+// promptAppID asks the user for the App ID of a workshop item. The question
+// goes straight to the terminal rather than to the item's output, which a
+// parallel batch worker only prints once the item is done.
+func promptAppID(workshopID string) (string, error) {
+	appIDPrompts.Lock()
+	defer appIDPrompts.Unlock()
+
+	fmt.Fprintf(humanOutput(), "Enter the Steam App ID for workshop item %s (see the game's store page URL): ", workshopID)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil && strings.TrimSpace(response) == "" {
+		return "", fmt.Errorf("App ID could not be determined; pass it with --app-id")
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// gameDirAppID infers the App ID from the appmanifest_<appid>.acf of the
+// Steam game install given with --game-dir, or that --output points into
+func gameDirAppID(w io.Writer) (string, bool) {
+	dir := viper.GetString("game_dir")
+	explicit := dir != ""
+	if dirs := outputDirs(); !explicit && len(dirs) > 0 {
+		dir = dirs[0]
+	}
+	if dir == "" {
+		return "", false
+	}
+
+	manifest, err := steamcmd.FindAppManifest(dir)
+	if err == nil && manifest != nil {
+		err = ValidateAppID(manifest.AppID)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "%s Could not read the App ID of %s: %v\n", iconWarn, dir, err)
+		return "", false
+	}
+	if manifest == nil {
+		if explicit {
+			fmt.Fprintf(w, "%s %s is not inside a Steam game install (no appmanifest_<appid>.acf found)\n", iconWarn, dir)
+		}
+		return "", false
+	}
+
+	fmt.Fprintf(w, "Using App ID %s (%s) from %s\n", manifest.AppID, manifest.Name, manifest.Path)
+	return manifest.AppID, true
+}
+
+// cachedItemInfo returns what an earlier run cached about a workshop item:
+// its App ID, and its title and game when they were found. Entries cached
+// before titles were kept get lookupTitle called once, and are then
+// remembered as looked up whatever it found, so a cache hit goes to the
+// network at most once.
+func cachedItemInfo(w io.Writer, workshopID string, lookupTitle func(info *scraper.WorkshopInfo)) (*scraper.WorkshopInfo, bool) {
+	store, err := openAppIDStore()
+	if err != nil {
+		return nil, false
+	}
+
+	entry, ok := store.Get(workshopID)
+	if !ok {
+		return nil, false
+	}
+	fmt.Fprintf(w, "Using cached App ID %s for workshop item %s\n", entry.AppID, workshopID)
+	info := &scraper.WorkshopInfo{
+		AppID:      entry.AppID,
+		WorkshopID: workshopID,
+		Title:      entry.Title,
+		GameName:   entry.Game,
+	}
+	if !entry.TitleFetched {
+		lookupTitle(info)
+		rememberItem(w, info)
+	}
+	return info, true
+}
+
+// openAppIDStore opens the App ID cache in the cache directory
+func openAppIDStore() (*appids.Store, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return appids.Open(dir)
+}
+
+// rememberItem caches the App ID, title and game of a workshop item for later
+// runs, right after they were looked up
+func rememberItem(w io.Writer, info *scraper.WorkshopInfo) {
+	store, err := openAppIDStore()
+	if err != nil {
+		fmt.Fprintf(w, "Warning: %v\n", err)
+		return
+	}
+
+	store.Set(info.WorkshopID, appids.Entry{AppID: info.AppID, Title: info.Title, Game: info.GameName, TitleFetched: true})
+	if err := store.Save(); err != nil {
+		fmt.Fprintf(w, "Warning: %v\n", err)
+	}
+}
+
+// isNumeric reports whether s is a non-negative 64-bit ID, the range of Steam
+// workshop and app IDs. Signs, spaces and values overflowing uint64 are rejected.
 func isNumeric(s string) bool {
-	_, err := strconv.Atoi(s)
+	_, err := strconv.ParseUint(s, 10, 64)
 	return err == nil
 }
 
@@ -221,100 +948,205 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-func handleOutput(item *steamcmd.WorkshopItem, outputDir, appID, workshopID string) error {
+func handleOutput(w io.Writer, item *steamcmd.WorkshopItem, outputDir, appID, workshopID string, opts fsutil.Options) error {
 	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
+	if err := opts.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Create a structured directory for the workshop item
-	itemOutputDir := filepath.Join(outputDir, fmt.Sprintf("app_%s_workshop_%s", appID, workshopID))
-	if err := os.MkdirAll(itemOutputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create item output directory: %w", err)
+	itemName := fmt.Sprintf("app_%s_workshop_%s", appID, workshopID)
+	itemBaseDir := filepath.Join(outputDir, itemName)
+
+	// With --staging-dir the item is extracted into this run's staging directory
+	staged := viper.GetString("staging_dir") != ""
+	stagedBaseDir := itemBaseDir
+	if staged {
+		stagedBaseDir = filepath.Join(stagingRunDir(), itemName)
 	}
 
-	// Copy the workshop item directory to the output location
-	if err := copyDirectory(item.PathToFile, itemOutputDir); err != nil {
-		return fmt.Errorf("failed to copy workshop item: %w", err)
+	// With --latest-link each download gets its own versioned directory
+	itemOutputDir, version := stagedBaseDir, ""
+	if viper.GetBool("latest_link") {
+		version = time.Now().UTC().Format(versionLayout)
+		itemOutputDir = filepath.Join(stagedBaseDir, version)
 	}
 
-	fmt.Printf("Workshop item extracted to: %s\n", itemOutputDir)
-	return nil
-}
+	if err := opts.MkdirAll(itemOutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create item output directory: %w", err)
+	}
 
-// copyDirectory recursively copies a directory from src to dst
-func copyDirectory(src, dst string) error {
-	// Get the source directory info
-	srcInfo, err := os.Stat(src)
+	// With --only-missing-files only what differs from the manifest is copied
+	expected, err := repairManifest()
 	if err != nil {
 		return err
 	}
+	if expected != nil {
+		if err := repairItemOutput(w, item, outputDir, itemName, expected, opts); err != nil {
+			return err
+		}
+	} else {
+		if err := copyItemOutput(w, item, itemOutputDir, opts); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Workshop item extracted to: %s\n", itemOutputDir)
+	}
 
-	// Create the destination directory
-	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
-		return err
+	if viper.GetBool("auto_unpack") {
+		if err := unpackArchives(w, itemOutputDir); err != nil {
+			return err
+		}
 	}
 
-	// Read the source directory
-	entries, err := os.ReadDir(src)
-	if err != nil {
+	if viper.GetBool("trim_output") {
+		if err := applyTrim(w, itemOutputDir); err != nil {
+			return err
+		}
+	}
+
+	// Paths are recorded as they'll be under outputDir once promoted
+	if err := recordOutputFiles(filepath.Dir(stagedBaseDir), itemOutputDir); err != nil {
 		return err
 	}
 
-	// Copy each entry
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
+	if staged {
+		if !viper.GetBool("promote") {
+			fmt.Fprintf(w, "%s Review the staged item, then run: workshop promote %s --output %s\n", iconTip, stagingRunDir(), outputDir)
+			return nil
+		}
 
-		if entry.IsDir() {
-			// Recursively copy subdirectories
-			if err := copyDirectory(srcPath, dstPath); err != nil {
-				return err
-			}
-		} else {
-			// Copy files
-			if err := copyFile(srcPath, dstPath); err != nil {
-				return err
-			}
+		if err := promoteStagedItem(stagedBaseDir, itemBaseDir, opts); err != nil {
+			return fmt.Errorf("failed to promote staged item: %w", err)
+		}
+		fmt.Fprintf(w, "%s Promoted to: %s\n", iconOK, itemBaseDir)
+
+		// Drop the run directory once it's empty; it's recreated for the next item
+		os.Remove(stagingRunDir())
+		return nil
+	}
+
+	if version != "" {
+		linkPath, err := updateLatestLink(itemBaseDir, version)
+		if err != nil {
+			return fmt.Errorf("failed to update latest link: %w", err)
 		}
+		fmt.Fprintf(w, "Latest link updated: %s\n", linkPath)
 	}
 
 	return nil
 }
 
-// copyFile copies a single file from src to dst
-func copyFile(src, dst string) error {
-	// Open source file
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return err
+// copyItemOutput copies the downloaded item into itemOutputDir, honoring --extract-file
+func copyItemOutput(w io.Writer, item *steamcmd.WorkshopItem, itemOutputDir string, opts fsutil.Options) error {
+	// Copy only the selected files when --extract-file is used
+	if pattern := viper.GetString("extract_file"); pattern != "" {
+		var copied int
+		opts.Include = []string{pattern}
+		opts.Progress = func(string, int64) { copied++ }
+		if err := fsutil.CopyDir(item.PathToFile, itemOutputDir, opts); err != nil {
+			return fmt.Errorf("failed to copy workshop item: %w", err)
+		}
+		if copied == 0 {
+			return fmt.Errorf("no files in %s match %q", item.PathToFile, pattern)
+		}
+		fmt.Fprintf(w, "Copied %d file(s) matching %q\n", copied, pattern)
+
+		if viper.GetBool("prune_cache") {
+			freed, err := pruneNonMatchingFiles(item.PathToFile, pattern)
+			if err != nil {
+				return fmt.Errorf("failed to prune download cache: %w", err)
+			}
+			fmt.Fprintf(w, "Pruned %s of unselected files from the download cache\n", formatBytes(freed))
+		}
+
+		return nil
 	}
-	defer srcFile.Close()
 
-	// Get source file info
-	srcInfo, err := srcFile.Stat()
-	if err != nil {
-		return err
+	// Copy the workshop item directory to the output location
+	if err := fsutil.CopyDir(item.PathToFile, itemOutputDir, opts); err != nil {
+		return fmt.Errorf("failed to copy workshop item: %w", err)
 	}
 
-	// Create destination file
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return err
+	return nil
+}
+
+// copyOptionsFromConfig reads and validates the dir_mode/file_mode/durable settings
+func copyOptionsFromConfig(w io.Writer) (fsutil.Options, error) {
+	var opts fsutil.Options
+	var err error
+
+	if opts.DirMode, err = parseFileMode(viper.GetString("dir_mode")); err != nil {
+		return opts, fmt.Errorf("invalid --dir-mode: %w", err)
+	}
+	if opts.FileMode, err = parseFileMode(viper.GetString("file_mode")); err != nil {
+		return opts, fmt.Errorf("invalid --file-mode: %w", err)
+	}
+	opts.Durable = viper.GetBool("durable")
+
+	// Files differing only by case overwrite each other on Windows and macOS
+	opts.CaseSafe = viper.GetBool("case_safe")
+	opts.OnCaseCollision = func(rel, earlier, renamed string) {
+		if renamed != "" {
+			fmt.Fprintf(w, "%s %s differs from %s only by case; written as %s\n", iconWarn, rel, earlier, renamed)
+			return
+		}
+		fmt.Fprintf(w, "%s %s differs from %s only by case; on a case-insensitive file system one overwrites the other (--case-safe renames it)\n", iconWarn, rel, earlier)
 	}
-	defer dstFile.Close()
 
-	// Copy the file contents
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return err
+	return opts, nil
+}
+
+// parseFileMode parses an octal permission string such as "0775" or "664".
+// An empty string yields 0, meaning "not set".
+func parseFileMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
 	}
 
-	// Set the file permissions to match the source
-	if err := os.Chmod(dst, srcInfo.Mode()); err != nil {
-		return err
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an octal mode", s)
+	}
+	if mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("%q must be between 0001 and 0777", s)
 	}
 
-	return nil
+	return os.FileMode(mode), nil
+}
+
+// pruneNonMatchingFiles removes the files under dir that don't match pattern
+// and returns the number of bytes freed
+func pruneNonMatchingFiles(dir, pattern string) (int64, error) {
+	var freed int64
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if fsutil.MatchGlob(pattern, rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		freed += info.Size()
+		return nil
+	})
+
+	return freed, err
 }
 
 // Additional helper functions for URL parsing and validation
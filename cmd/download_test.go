@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/appids"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/manifest"
+	"github.com/spf13/viper"
+)
+
+func TestIsNumeric(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"0", true},
+		{"2503622437", true},
+		{"18446744073709551615", true}, // max uint64
+		{"18446744073709551616", false},
+		{"99999999999999999999999", false},
+		{"-5", false},
+		{"+5", false},
+		{"", false},
+		{" 5", false},
+		{"5 ", false},
+		{"1_000", false},
+		{"0x10", false},
+		{"12a", false},
+	}
+
+	for _, tt := range tests {
+		if got := isNumeric(tt.input); got != tt.want {
+			t.Errorf("isNumeric(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWorkshopID(t *testing.T) {
+	valid := []string{"1", "2503622437", "18446744073709551615"}
+	invalid := []string{"", "-5", "+5", "18446744073709551616", "abc"}
+
+	for _, id := range valid {
+		if err := ValidateWorkshopID(id); err != nil {
+			t.Errorf("ValidateWorkshopID(%q) unexpected error = %v", id, err)
+		}
+	}
+	for _, id := range invalid {
+		if err := ValidateWorkshopID(id); err == nil {
+			t.Errorf("ValidateWorkshopID(%q) expected an error", id)
+		}
+	}
+}
+
+func TestValidateAppID(t *testing.T) {
+	valid := []string{"440", "108600", "4294967295"}
+	invalid := []string{"", "-440", "+440", "12345678901", "4x0"}
+
+	for _, id := range valid {
+		if err := ValidateAppID(id); err != nil {
+			t.Errorf("ValidateAppID(%q) unexpected error = %v", id, err)
+		}
+	}
+	for _, id := range invalid {
+		if err := ValidateAppID(id); err == nil {
+			t.Errorf("ValidateAppID(%q) expected an error", id)
+		}
+	}
+}
+
+func TestOutputManifest(t *testing.T) {
+	out := t.TempDir()
+	item := filepath.Join(out, "app_108600_workshop_1")
+	os.MkdirAll(filepath.Join(item, "media"), 0755)
+	os.WriteFile(filepath.Join(item, "mod.info"), []byte("info"), 0644)
+	os.WriteFile(filepath.Join(item, "media", "main.lua"), []byte("lua"), 0644)
+
+	viper.Set("output_manifest", filepath.Join(out, "files.txt"))
+	viper.Set("output_manifest_hashes", true)
+	defer viper.Set("output_manifest", nil)
+	defer viper.Set("output_manifest_hashes", nil)
+
+	err := withOutputManifest(func() error { return recordOutputFiles(out, item) })
+	if err != nil {
+		t.Fatalf("withOutputManifest() unexpected error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "files.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# path\tsize\tsha256\n" +
+		"app_108600_workshop_1/media/main.lua\t3\tdc436b329f4da6c88b0b6ce79d829ac4fc33746b454604e2bcbad25a8e2985fe\n" +
+		"app_108600_workshop_1/mod.info\t4\t06271baf49532c879aa3c58b48671884bcc858f09197412d682750496c33e1e1\n"
+	if string(data) != want {
+		t.Errorf("output manifest =\n%s\nwant\n%s", data, want)
+	}
+}
+
+// roundTripFunc stubs a transport
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestParseDownloadInputCachedItem(t *testing.T) {
+	dir := t.TempDir()
+	viper.Set("cache_dir", dir)
+	defer viper.Set("cache_dir", nil)
+
+	// Count the lookups instead of going to the network, failing each one
+	var requests int
+	defer func(c *http.Client) { httpclient.Base = c }(httpclient.Base)
+	httpclient.Base = &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		requests++
+		return nil, errors.New("offline")
+	})}
+	defer func(n uint64) { httpclient.MaxRetries = n }(httpclient.MaxRetries)
+	httpclient.MaxRetries = 0
+
+	store, err := appids.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("1", appids.Entry{AppID: "108600", Title: "Better Sorting", Game: "Project Zomboid", TitleFetched: true})
+	store.Set("2", appids.Entry{AppID: "108600", TitleFetched: true}) // Looked up without finding a title
+	store.Set("3", appids.Entry{AppID: "108600"})                     // Cached by an earlier version
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	const url = "https://steamcommunity.com/sharedfiles/filedetails/?id="
+	tests := []struct {
+		input        string
+		wantTitle    string
+		wantRequests int
+	}{
+		{url + "1", "Better Sorting", 0},
+		{"1", "Better Sorting", 0},
+		{url + "2", "", 0},
+		{"2", "", 0},
+		{url + "3", "", 1}, // Tried once, then remembered as tried
+		{url + "3", "", 0},
+		{"3", "", 0},
+	}
+
+	for _, tt := range tests {
+		requests = 0
+		appID, workshopID, info, err := parseDownloadInput(io.Discard, []string{tt.input})
+		if err != nil {
+			t.Fatalf("parseDownloadInput(%q) unexpected error = %v", tt.input, err)
+		}
+		if appID != "108600" || !strings.HasSuffix(tt.input, workshopID) {
+			t.Errorf("parseDownloadInput(%q) = %s, %s; want the cached App ID", tt.input, appID, workshopID)
+		}
+		if info == nil || info.Title != tt.wantTitle {
+			t.Errorf("parseDownloadInput(%q) info = %+v, want title %q", tt.input, info, tt.wantTitle)
+		}
+		if requests != tt.wantRequests {
+			t.Errorf("parseDownloadInput(%q) made %d requests, want %d", tt.input, requests, tt.wantRequests)
+		}
+	}
+
+	// The old entry is now marked as looked up
+	store, err = appids.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := store.Get("3"); !entry.TitleFetched {
+		t.Error("an entry whose title was looked up should be remembered as looked up")
+	}
+}
+
+func TestUniqueItems(t *testing.T) {
+	entries := []manifest.Item{
+		{AppID: "108600", WorkshopID: "1"},
+		{AppID: "108600", WorkshopID: "2"},
+		{AppID: "108600", WorkshopID: "1"},
+		{AppID: "4000", WorkshopID: "1"},
+	}
+	want := []batchItem{{"108600", "1"}, {"108600", "2"}, {"4000", "1"}}
+	if got := uniqueItems(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueItems() = %v, want %v", got, want)
+	}
+}
+
+func TestBatchSummaryPhaseTotals(t *testing.T) {
+	summary := &batchSummary{}
+	summary.add(&downloadResult{Status: statusDownloaded, Timings: []phaseTiming{{phaseLookup, 1}, {phaseDownload, 10}}})
+	summary.add(&downloadResult{Status: statusFailed, Timings: []phaseTiming{{phaseLookup, 0.5}}})
+	summary.add(&downloadResult{Status: statusSkipped})
+
+	want := map[string]float64{phaseLookup: 1.5, phaseDownload: 10}
+	if !reflect.DeepEqual(summary.PhaseSeconds, want) {
+		t.Errorf("PhaseSeconds = %v, want %v", summary.PhaseSeconds, want)
+	}
+}
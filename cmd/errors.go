@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/scraper"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+)
+
+// errorCodes maps typed errors to the stable codes reported in JSON output
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{steamcmd.ErrNotLoggedOn, "not_logged_on"},
+	{steamcmd.ErrLoginFailed, "login_failed"},
+	{steamcmd.ErrAccessDenied, "access_denied"},
+	{steamcmd.ErrNotOwned, "not_owned"},
+	{steamcmd.ErrItemNotFound, "item_not_found"},
+	{steamcmd.ErrTimeout, "timeout"},
+	{steamcmd.ErrRateLimited, "rate_limited"},
+	{steamcmd.ErrNetwork, "network"},
+	{steamcmd.ErrSteamCMDFailed, "steamcmd_failed"},
+	{steamcmd.ErrUnexpectedOutput, "unexpected_output"},
+	{steamcmd.ErrDownloadFailed, "download_failed"},
+	{steamcmd.ErrEmptyDownload, "empty_download"},
+	{scraper.ErrAppIDNotFound, "app_id_not_found"},
+	{context.DeadlineExceeded, "timeout"},
+}
+
+// errorCode returns the code of the first typed error err wraps, or "error"
+func errorCode(err error) string {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	var statusErr *httpclient.StatusError
+	if errors.As(err, &statusErr) {
+		return "http_error"
+	}
+
+	return "error"
+}
+
+// itemError attaches the workshop item a failure belongs to
+type itemError struct {
+	AppID      string
+	WorkshopID string
+	Err        error
+}
+
+func (e *itemError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *itemError) Unwrap() error {
+	return e.Err
+}
+
+// jsonError is the structured form of an error written in JSON mode
+type jsonError struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		AppID      string `json:"app_id,omitempty"`
+		WorkshopID string `json:"workshop_id,omitempty"`
+	} `json:"error"`
+}
+
+// ReportError writes err to w: as a JSON object with --output-format json
+// (or --json-lines), so wrappers can parse failures like results, and as
+// plain text otherwise.
+func ReportError(w io.Writer, err error) {
+	if !jsonOutput() {
+		fmt.Fprintln(w, "Error:", err)
+		return
+	}
+
+	var out jsonError
+	out.Error.Code = errorCode(err)
+	out.Error.Message = err.Error()
+
+	var itemErr *itemError
+	if errors.As(err, &itemErr) {
+		out.Error.AppID = itemErr.AppID
+		out.Error.WorkshopID = itemErr.WorkshopID
+	}
+
+	json.NewEncoder(w).Encode(out)
+}
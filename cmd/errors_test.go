@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+func TestReportError(t *testing.T) {
+	err := &itemError{
+		AppID:      "108600",
+		WorkshopID: "42",
+		Err:        fmt.Errorf("download failed: %w", steamcmd.ErrAccessDenied),
+	}
+
+	var text bytes.Buffer
+	ReportError(&text, err)
+	if want := "Error: download failed: access denied\n"; text.String() != want {
+		t.Errorf("text ReportError() = %q, want %q", text.String(), want)
+	}
+
+	for _, key := range []string{"output_format", "json_lines"} {
+		t.Run(key, func(t *testing.T) {
+			if key == "json_lines" {
+				viper.Set(key, true)
+			} else {
+				viper.Set(key, "json")
+			}
+			defer viper.Set(key, nil)
+
+			var buf bytes.Buffer
+			ReportError(&buf, err)
+
+			var got jsonError
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("ReportError() wrote %q, not a JSON object: %v", buf.String(), err)
+			}
+			if got.Error.Code != "access_denied" || got.Error.AppID != "108600" || got.Error.WorkshopID != "42" {
+				t.Errorf("ReportError() = %+v, want code access_denied for app 108600, item 42", got.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// exitFuncs must run however the run ends. Deferred calls are skipped when
+// Ctrl+C or termination ends the process, so Execute runs them on both paths.
+var (
+	exitMu    sync.Mutex
+	exitFuncs []func()
+)
+
+// atExit registers fn to run when the run ends, before the functions
+// registered earlier
+func atExit(fn func()) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitFuncs = append(exitFuncs, fn)
+}
+
+// runExitFuncs runs the registered functions, newest first, and forgets them
+// so a second call does nothing
+func runExitFuncs() {
+	exitMu.Lock()
+	fns := exitFuncs
+	exitFuncs = nil
+	exitMu.Unlock()
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
+	}
+}
+
+// exitOnSignal runs the registered functions and exits with status 130 on
+// Ctrl+C or termination. The returned function stops listening.
+func exitOnSignal(start time.Time) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			runExitFuncs()
+			logRunFinished(nil, fmt.Errorf("interrupted by %v", sig), time.Since(start))
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
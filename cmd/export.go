@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/manifest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export downloaded workshop items to a shareable manifest",
+	Long: `Write a YAML manifest of every downloaded workshop item.
+
+The manifest can be committed to version control and replayed on another
+machine with 'workshop import'.
+
+Examples:
+  workshop export --out mods.yaml
+  workshop export > mods.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportManifest()
+	},
+}
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [manifest]",
+	Short: "Download every workshop item listed in a manifest",
+	Long: `Download every workshop item listed in a manifest written by 'workshop export'.
+
+Items that fail are reported at the end; the remaining items are still downloaded.
+
+Example:
+  workshop import mods.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := lockSteamCMD(); err != nil {
+			return err
+		}
+		return withManifestOut(func() error { return importManifest(args[0]) })
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	exportCmd.Flags().String("out", "", "Manifest file to write (default: stdout)")
+	bindFlag("export_out", exportCmd.Flags().Lookup("out"))
+}
+
+func exportManifest() error {
+	client, err := newSteamCMDClient(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to create SteamCMD client: %w", err)
+	}
+
+	items, err := downloadedItems(client, "")
+	if err != nil {
+		return err
+	}
+
+	// Sorted for a stable, diff-friendly manifest
+	m := &manifest.Manifest{}
+	for _, item := range items {
+		m.Items = append(m.Items, manifest.Item{AppID: item.AppID, WorkshopID: item.WorkshopID})
+	}
+
+	out := viper.GetString("export_out")
+	if out == "" {
+		data, err := m.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := m.Save(out); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d workshop items to %s\n", len(m.Items), out)
+	return nil
+}
+
+func importManifest(path string) error {
+	m, err := manifest.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if len(m.Items) == 0 {
+		fmt.Println("Manifest contains no workshop items.")
+		return nil
+	}
+
+	items := make([]batchItem, 0, len(m.Items))
+	for _, item := range m.Items {
+		items = append(items, batchItem{AppID: item.AppID, WorkshopID: item.WorkshopID})
+	}
+
+	return downloadBatch(items, path)
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+// flatIndexName is the file in a flat game directory recording which
+// workshop item each file came from
+const flatIndexName = ".workshop-flat.json"
+
+// What --flat-collision does with a file another item (or the user) already put there
+const (
+	flatCollisionSkip      = "skip"      // Keep the existing file
+	flatCollisionOverwrite = "overwrite" // Replace it with this item's file
+	flatCollisionRename    = "rename"    // Write this item's file as name_<workshop id>.ext
+)
+
+var flatCollisionStrategies = []string{flatCollisionSkip, flatCollisionOverwrite, flatCollisionRename}
+
+// flatOutputMu serializes merges, since parallel batch downloads share the
+// game directory and its index
+var flatOutputMu sync.Mutex
+
+// validateFlatCollision checks the --flat-collision setting
+func validateFlatCollision(strategy string) error {
+	for _, s := range flatCollisionStrategies {
+		if strategy == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --flat-collision %q: must be one of %s", strategy, strings.Join(flatCollisionStrategies, ", "))
+}
+
+// flatGameDir is the directory --output-flat-per-game merges a game's items into
+func flatGameDir(outputDir, appID string) string {
+	return filepath.Join(outputDir, "app_"+appID)
+}
+
+// handleFlatOutput merges the item's files into the game's shared directory
+// under outputDir, for games that load every mod from one folder. Files the
+// item put there on an earlier download are replaced, and removed when the
+// new version no longer has them; files of other items are handled per
+// --flat-collision.
+func handleFlatOutput(w io.Writer, item *steamcmd.WorkshopItem, outputDir, appID, workshopID string, opts fsutil.Options) error {
+	flatOutputMu.Lock()
+	defer flatOutputMu.Unlock()
+
+	gameDir := flatGameDir(outputDir, appID)
+	if err := opts.MkdirAll(gameDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	index, err := loadFlatIndex(gameDir)
+	if err != nil {
+		return err
+	}
+
+	strategy := viper.GetString("flat_collision")
+	pattern := viper.GetString("extract_file")
+
+	var written []string
+	var skipped, renamed int
+	err = filepath.WalkDir(item.PathToFile, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(item.PathToFile, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if pattern != "" && !fsutil.MatchGlob(pattern, rel) {
+			return nil
+		}
+
+		if owner, ok := index[rel]; !ok || owner != workshopID {
+			if _, err := os.Lstat(filepath.Join(gameDir, rel)); err == nil {
+				by := "a file not from any workshop item"
+				if ok {
+					by = "workshop item " + owner
+				}
+
+				switch strategy {
+				case flatCollisionSkip:
+					fmt.Fprintf(w, "%s %s already exists (%s); keeping it\n", iconWarn, rel, by)
+					skipped++
+					return nil
+				case flatCollisionOverwrite:
+					fmt.Fprintf(w, "%s %s already exists (%s); overwriting it\n", iconWarn, rel, by)
+				case flatCollisionRename:
+					renamedRel := flatRenamed(rel, workshopID)
+					fmt.Fprintf(w, "%s %s already exists (%s); writing %s instead\n", iconWarn, rel, by, renamedRel)
+					rel = renamedRel
+					renamed++
+				}
+			}
+		}
+
+		dst := filepath.Join(gameDir, filepath.FromSlash(rel))
+		if err := opts.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := fsutil.CopyFile(path, dst, opts); err != nil {
+			return err
+		}
+		index[rel] = workshopID
+		written = append(written, rel)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge workshop item: %w", err)
+	}
+	if len(written) == 0 && skipped == 0 && pattern != "" {
+		return fmt.Errorf("no files in %s match %q", item.PathToFile, pattern)
+	}
+
+	// Drop what an earlier version of the item left behind
+	current := make(map[string]bool, len(written))
+	for _, rel := range written {
+		current[rel] = true
+	}
+	var removed int
+	for rel, owner := range index {
+		if owner != workshopID || current[rel] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(gameDir, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old file of the item: %w", err)
+		}
+		delete(index, rel)
+		removed++
+	}
+
+	if err := saveFlatIndex(gameDir, index); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Merged %d file(s) of workshop item %s into: %s\n", len(written), workshopID, gameDir)
+	if skipped+renamed+removed > 0 {
+		fmt.Fprintf(w, "  %d kept because they already existed, %d renamed, %d from the previous version removed\n", skipped, renamed, removed)
+	}
+
+	paths := make([]string, len(written))
+	for i, rel := range written {
+		paths[i] = filepath.Join(gameDir, filepath.FromSlash(rel))
+	}
+	return recordOutputPaths(outputDir, paths)
+}
+
+// flatRenamed returns the name a colliding file is written under with
+// --flat-collision rename: the workshop ID appended before the extension
+func flatRenamed(rel, workshopID string) string {
+	ext := filepath.Ext(rel)
+	return strings.TrimSuffix(rel, ext) + "_" + workshopID + ext
+}
+
+// loadFlatIndex reads the file -> workshop ID index of a flat game directory
+func loadFlatIndex(gameDir string) (map[string]string, error) {
+	index := make(map[string]string)
+
+	path := filepath.Join(gameDir, flatIndexName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return index, nil
+}
+
+// saveFlatIndex writes the index of a flat game directory. encoding/json
+// sorts the paths, so the file diffs cleanly.
+func saveFlatIndex(gameDir string, index map[string]string) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(gameDir, flatIndexName)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
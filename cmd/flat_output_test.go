@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+func TestFlatOutput(t *testing.T) {
+	defer viper.Set("flat_collision", nil)
+	out := t.TempDir()
+
+	writeItem := func(files map[string]string) *steamcmd.WorkshopItem {
+		dir := t.TempDir()
+		for name, content := range files {
+			os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+			os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		}
+		return &steamcmd.WorkshopItem{PathToFile: dir}
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(flatGameDir(out, "108600"), name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	// Two items shipping the same file: the first one keeps it
+	viper.Set("flat_collision", flatCollisionSkip)
+	if err := handleFlatOutput(io.Discard, writeItem(map[string]string{"shared.pak": "one", "old.pak": "one"}), out, "108600", "1", fsutil.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := handleFlatOutput(io.Discard, writeItem(map[string]string{"shared.pak": "two", "sub/two.pak": "two"}), out, "108600", "2", fsutil.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("shared.pak"); got != "one" {
+		t.Errorf("shared.pak = %q after skip, want %q", got, "one")
+	}
+	if got := read("sub/two.pak"); got != "two" {
+		t.Errorf("sub/two.pak = %q, want %q", got, "two")
+	}
+
+	// A new version of item 1 replaces its files and drops the ones it lost
+	if err := handleFlatOutput(io.Discard, writeItem(map[string]string{"shared.pak": "one v2"}), out, "108600", "1", fsutil.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("shared.pak"); got != "one v2" {
+		t.Errorf("shared.pak = %q after update, want %q", got, "one v2")
+	}
+	if got := read("old.pak"); got != "" {
+		t.Errorf("old.pak = %q, want it removed with the new version", got)
+	}
+
+	viper.Set("flat_collision", flatCollisionOverwrite)
+	if err := handleFlatOutput(io.Discard, writeItem(map[string]string{"shared.pak": "two"}), out, "108600", "2", fsutil.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("shared.pak"); got != "two" {
+		t.Errorf("shared.pak = %q after overwrite, want %q", got, "two")
+	}
+	index, err := loadFlatIndex(flatGameDir(out, "108600"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index["shared.pak"] != "2" || index["sub/two.pak"] != "" {
+		t.Errorf("index = %v, want shared.pak owned by 2 and sub/two.pak dropped", index)
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+// untrackedWarning explains once per run what placing items behind the
+// Steam client's back means
+var untrackedWarning sync.Once
+
+// steamLibraries returns the Steam client libraries of the install set with
+// steam_client_dir, or of those found on this machine
+func steamLibraries() ([]string, error) {
+	steamDirs := steamcmd.SystemSteamDirs()
+	if dir := viper.GetString("steam_client_dir"); dir != "" {
+		steamDirs = []string{dir}
+	}
+	if len(steamDirs) == 0 {
+		return nil, fmt.Errorf("no Steam client install found; set %s to its directory", envName("steam_client_dir"))
+	}
+	return steamcmd.SteamLibraries(steamDirs)
+}
+
+// gameInstallDir returns where --install-to-game puts an item: the workshop
+// content directory the Steam client loads the game's mods from. It fails for
+// items the Steam client manages itself, which are never touched.
+func gameInstallDir(appID, workshopID string) (string, error) {
+	libraries, err := steamLibraries()
+	if err != nil {
+		return "", err
+	}
+	library, err := steamcmd.GameLibrary(libraries, appID)
+	if err != nil {
+		return "", err
+	}
+
+	managed, err := steamcmd.ClientManagesItem(library, appID, workshopID)
+	if err != nil {
+		return "", err
+	}
+	if managed {
+		return "", fmt.Errorf("the Steam client manages item %s in %s (the account is subscribed to it) and would overwrite or remove a copy; "+
+			"unsubscribe from it first, or use --output", workshopID, library)
+	}
+
+	return filepath.Join(steamcmd.GameWorkshopDir(library, appID), workshopID), nil
+}
+
+// installToGame copies a downloaded item into the game's workshop content
+// directory and returns where it went
+func installToGame(w io.Writer, item *steamcmd.WorkshopItem, appID, workshopID string, opts fsutil.Options) (string, error) {
+	target, err := gameInstallDir(appID, workshopID)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Clean(item.PathToFile) == target {
+		// SteamCMD already downloaded into the game's library
+		return target, nil
+	}
+	contentDir := filepath.Dir(target)
+
+	untrackedWarning.Do(func() {
+		fmt.Fprintf(w, "%s Items installed into the game aren't tracked by the Steam client: verifying the game's files may remove them, "+
+			"and games that only load subscribed items won't see them\n", iconWarn)
+	})
+
+	if _, err := os.Stat(target); err == nil {
+		fmt.Fprintf(w, "%s Replacing the copy at %s, which the Steam client doesn't track\n", iconWarn, target)
+	}
+
+	if err := opts.MkdirAll(contentDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", contentDir, err)
+	}
+
+	// Copy next to the target and swap it in, so the game never loads a
+	// half-copied item
+	tmp, err := os.MkdirTemp(contentDir, "."+workshopID+".tmp-")
+	if err != nil {
+		return "", err
+	}
+	if err := fsutil.CopyDir(item.PathToFile, tmp, opts); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("failed to copy workshop item: %w", err)
+	}
+	if err := os.RemoveAll(target); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("failed to remove the previous copy: %w", err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("failed to move the item into place: %w", err)
+	}
+
+	return target, nil
+}
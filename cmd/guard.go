@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamguard"
+	"github.com/spf13/viper"
+)
+
+// guardCodeSource returns the Steam Guard code source configured under
+// steam_guard.imap, or nil to prompt for codes
+func guardCodeSource() steamcmd.GuardCodeSource {
+	host := viper.GetString("steam_guard.imap.host")
+	if host == "" {
+		return nil
+	}
+
+	return &steamguard.IMAP{
+		Addr:     host,
+		Username: viper.GetString("steam_guard.imap.username"),
+		Password: viper.GetString("steam_guard.imap.password"),
+		Mailbox:  viper.GetString("steam_guard.imap.mailbox"),
+		Subject:  viper.GetString("steam_guard.imap.subject"),
+		Timeout:  viper.GetDuration("steam_guard.imap.timeout"),
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/archive"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [workshop ID]",
+	Short: "Show the archives inside a downloaded workshop item",
+	Long: `Show the archives (vpk, zip, pak) inside a downloaded workshop item.
+
+With --list, also list the files inside each vpk and zip archive without
+extracting them. Multi-part VPKs are listed through their _dir.vpk file.
+The item is looked up among the downloaded items; pass --app-id when the
+same workshop ID was downloaded for several games.
+
+Examples:
+  workshop inspect 2503622437
+  workshop inspect 2503622437 --app-id 4000 --list`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return inspectItem(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().String("app-id", "", "Steam App ID of the item (default: found among downloaded items)")
+	inspectCmd.Flags().Bool("list", false, "List the files inside each archive")
+	bindFlag("inspect_app_id", inspectCmd.Flags().Lookup("app-id"))
+	bindFlag("inspect_list", inspectCmd.Flags().Lookup("list"))
+}
+
+func inspectItem(workshopID string) error {
+	if err := ValidateWorkshopID(workshopID); err != nil {
+		return err
+	}
+
+	itemDir, err := findDownloadedItem(viper.GetString("inspect_app_id"), workshopID)
+	if err != nil {
+		return err
+	}
+
+	var archives []string
+	filepath.WalkDir(itemDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Continue on errors
+		}
+		if !d.IsDir() && archive.Type(path) != "" {
+			archives = append(archives, path)
+		}
+		return nil
+	})
+
+	fmt.Printf("%s %s\n", iconDir, itemDir)
+	if len(archives) == 0 {
+		fmt.Println("No vpk, zip or pak archives found")
+		return nil
+	}
+
+	list := viper.GetBool("inspect_list")
+	for _, path := range archives {
+		rel, err := filepath.Rel(itemDir, path)
+		if err != nil {
+			rel = path
+		}
+
+		entries, err := archive.List(path)
+		if errors.Is(err, archive.ErrUnsupported) {
+			fmt.Printf("\n%s (%s, listing not supported yet)\n", filepath.ToSlash(rel), archive.Type(path))
+			continue
+		}
+		if err != nil {
+			fmt.Printf("\n%s %s: %v\n", iconWarn, filepath.ToSlash(rel), err)
+			continue
+		}
+
+		var total int64
+		for _, e := range entries {
+			total += e.Size
+		}
+		fmt.Printf("\n%s (%s, %d file(s), %s)\n", filepath.ToSlash(rel), archive.Type(path), len(entries), formatBytes(total))
+
+		if list {
+			for _, e := range entries {
+				fmt.Printf("  %10s  %s\n", formatBytes(e.Size), e.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findDownloadedItem returns the directory of a downloaded item. Without an
+// App ID it searches every game's downloads for the workshop ID.
+func findDownloadedItem(appID, workshopID string) (string, error) {
+	client, err := newSteamCMDClient(os.Stdout)
+	if err != nil {
+		return "", fmt.Errorf("failed to create SteamCMD client: %w", err)
+	}
+
+	if appID == "" {
+		apps, err := downloadedApps(client, workshopID)
+		if err != nil {
+			return "", err
+		}
+		switch len(apps) {
+		case 0:
+			return "", fmt.Errorf("workshop item %s is not downloaded", workshopID)
+		case 1:
+			appID = apps[0]
+		default:
+			return "", fmt.Errorf("workshop item %s is downloaded for several apps (%v); pass --app-id", workshopID, apps)
+		}
+	} else if err := ValidateAppID(appID); err != nil {
+		return "", err
+	}
+
+	exists, path, err := client.CheckWorkshopItemExists(appID, workshopID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check workshop item: %w", err)
+	}
+	if !exists {
+		return "", fmt.Errorf("workshop item %s for app %s is not downloaded", workshopID, appID)
+	}
+	return path, nil
+}
@@ -3,7 +3,11 @@ package cmd
 import (
 	"archive/tar"
 	"archive/zip"
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +17,10 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/backoff"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/sethvargo/go-retry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -38,7 +46,7 @@ func init() {
 	rootCmd.AddCommand(installCmd)
 
 	installCmd.Flags().BoolP("force", "f", false, "Force reinstall even if SteamCMD already exists")
-	viper.BindPFlag("force_install", installCmd.Flags().Lookup("force"))
+	bindFlag("force_install", installCmd.Flags().Lookup("force"))
 }
 
 func installSteamCMD() error {
@@ -53,12 +61,21 @@ func installSteamCMD() error {
 		steamcmdExe = filepath.Join(steamcmdDir, "steamcmd.sh")
 	}
 
-	if !force {
-		if _, err := os.Stat(steamcmdExe); err == nil {
+	// An existing install is only kept if it's healthy: a crash mid-extract
+	// leaves the script in place with its binaries missing or truncated
+	if _, err := os.Stat(steamcmdExe); err == nil && !force {
+		if err := lockSteamCMD(); err != nil {
+			return err
+		}
+
+		problem := checkSteamCMDInstall(steamcmdDir, steamcmdExe)
+		if problem == "" {
 			fmt.Printf("SteamCMD already exists at %s\n", steamcmdExe)
 			fmt.Println("Use --force to reinstall")
 			return nil
 		}
+		fmt.Printf("%s SteamCMD at %s looks broken: %s\n", iconWarn, steamcmdDir, problem)
+		fmt.Println("Repairing the installation...")
 	}
 
 	// Create steamcmd directory
@@ -66,6 +83,10 @@ func installSteamCMD() error {
 		return fmt.Errorf("failed to create SteamCMD directory: %w", err)
 	}
 
+	if err := lockSteamCMD(); err != nil {
+		return err
+	}
+
 	// Get download URL based on OS
 	downloadURL, filename := getSteamCMDDownloadURL()
 
@@ -77,6 +98,12 @@ func installSteamCMD() error {
 		return fmt.Errorf("failed to download SteamCMD: %w", err)
 	}
 
+	// A CDN error page served with a 200 would otherwise fail much later in extraction
+	if err := verifyArchive(tempFile); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to download SteamCMD from %s: %w", downloadURL, err)
+	}
+
 	fmt.Println("Extracting SteamCMD...")
 
 	// Extract based on file type
@@ -92,15 +119,57 @@ func installSteamCMD() error {
 	// Run initial SteamCMD update
 	fmt.Println("Running initial SteamCMD update...")
 	if err := runInitialSteamCMDUpdate(steamcmdExe); err != nil {
-		fmt.Printf("Warning: Initial update failed: %v\n", err)
-		fmt.Println("You may need to run SteamCMD manually the first time")
-	} else {
-		fmt.Println("SteamCMD installation completed successfully!")
+		fmt.Printf("%s SteamCMD is installed but hasn't finished updating itself; run 'workshop install' again to resume\n", iconTip)
+		return err
 	}
 
+	fmt.Println("SteamCMD installation completed successfully!")
 	return nil
 }
 
+// steamCMDBinaries are the files, relative to the SteamCMD directory, that
+// steamcmd.sh needs besides itself. Windows ships a single self-updating exe.
+func steamCMDBinaries() []string {
+	switch runtime.GOOS {
+	case "linux":
+		return []string{filepath.Join("linux32", "steamcmd")}
+	default:
+		return nil
+	}
+}
+
+// checkSteamCMDInstall returns why an existing SteamCMD install can't be used,
+// or "" when it's healthy
+func checkSteamCMDInstall(steamcmdDir, steamcmdExe string) string {
+	for _, path := range append([]string{steamcmdExe}, steamCMDBinaries()...) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(steamcmdDir, path)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Sprintf("%s is missing", path)
+		}
+		if info.Size() == 0 {
+			return fmt.Sprintf("%s is empty", path)
+		}
+		if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+			return fmt.Sprintf("%s is not executable", path)
+		}
+	}
+
+	// The files look right; make sure SteamCMD actually starts
+	client, err := steamcmd.NewClient(steamcmdDir)
+	if err != nil {
+		return err.Error()
+	}
+	if err := client.TestConnection(); errors.Is(err, steamcmd.ErrSteamCMDFailed) {
+		return err.Error()
+	}
+
+	return ""
+}
+
 func getSteamCMDDownloadURL() (string, string) {
 	baseURL := "https://steamcdn-a.akamaihd.net/client/installer/"
 
@@ -115,16 +184,15 @@ func getSteamCMDDownloadURL() (string, string) {
 }
 
 func downloadFile(url, filepath string) error {
-	resp, err := http.Get(url)
+	// No timeout: the archive can take a while on slow connections
+	client := httpclient.New(0)
+
+	resp, err := client.Get(context.Background(), url)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
-	}
-
 	out, err := os.Create(filepath)
 	if err != nil {
 		return err
@@ -135,6 +203,49 @@ func downloadFile(url, filepath string) error {
 	return err
 }
 
+// Leading bytes of the archive formats SteamCMD is distributed in
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// verifyArchive checks that the file starts with the magic bytes its extension
+// promises, and reports an HTML page explicitly since that's what a misbehaving
+// CDN returns
+func verifyArchive(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+
+	var magic []byte
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		magic = zipMagic
+	case strings.HasSuffix(path, ".tar.gz"):
+		magic = gzipMagic
+	default:
+		return nil
+	}
+
+	if bytes.HasPrefix(head, magic) {
+		return nil
+	}
+
+	if n == 0 {
+		return fmt.Errorf("the server returned an empty file instead of %s", filepath.Base(path))
+	}
+	if strings.HasPrefix(http.DetectContentType(head), "text/html") {
+		return fmt.Errorf("the server returned an HTML page instead of %s; the CDN may be having problems, try again later", filepath.Base(path))
+	}
+	return fmt.Errorf("%s is not a valid archive (unexpected leading bytes % x)", filepath.Base(path), head[:min(n, len(magic))])
+}
+
 func extractSteamCMD(archivePath, destDir string) error {
 	if runtime.GOOS == "windows" {
 		return extractZip(archivePath, destDir)
@@ -143,6 +254,25 @@ func extractSteamCMD(archivePath, destDir string) error {
 	}
 }
 
+// extractPath returns where the archive entry name is extracted under dest.
+// It fails for names that would land outside dest (zip-slip), whichever
+// separator they use: backslashes are taken as separators too, as archives
+// made on Windows use them.
+func extractPath(dest, name string) (string, error) {
+	rel := filepath.FromSlash(strings.ReplaceAll(name, `\`, "/"))
+	if filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" || strings.HasPrefix(rel, string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path: %s", name)
+	}
+
+	target := filepath.Join(dest, rel)
+	rel, err := filepath.Rel(filepath.Clean(dest), target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path: %s", name)
+	}
+
+	return target, nil
+}
+
 func extractZip(src, dest string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -155,12 +285,10 @@ func extractZip(src, dest string) error {
 
 	// Extract files and folders
 	for _, f := range r.File {
-		// Create the destination path
-		path := filepath.Join(dest, f.Name)
-
-		// Security check: ensure the file path is within the destination directory
-		if !strings.HasPrefix(path, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("invalid file path: %s", f.Name)
+		// Create the destination path, refusing entries that escape dest
+		path, err := extractPath(dest, f.Name)
+		if err != nil {
+			return err
 		}
 
 		if f.FileInfo().IsDir() {
@@ -193,6 +321,11 @@ func extractZip(src, dest string) error {
 		if err != nil {
 			return err
 		}
+
+		// OpenFile keeps the mode of a file left by an earlier, broken install
+		if err := os.Chmod(path, f.FileInfo().Mode()); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -211,7 +344,12 @@ func extractTarGz(src, dest string) error {
 	}
 	defer gzr.Close()
 
-	tr := tar.NewReader(gzr)
+	return extractTar(gzr, dest)
+}
+
+// extractTar extracts the directories and regular files of a tar stream into dest
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
 
 	for {
 		header, err := tr.Next()
@@ -222,11 +360,10 @@ func extractTarGz(src, dest string) error {
 			return err
 		}
 
-		target := filepath.Join(dest, header.Name)
-
 		// Ensure the target is within dest directory
-		if !filepath.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("invalid file path: %s", header.Name)
+		target, err := extractPath(dest, header.Name)
+		if err != nil {
+			return err
 		}
 
 		switch header.Typeflag {
@@ -239,7 +376,8 @@ func extractTarGz(src, dest string) error {
 				return err
 			}
 
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			// Truncate files left by an earlier, broken install
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
 				return err
 			}
@@ -249,40 +387,102 @@ func extractTarGz(src, dest string) error {
 				return err
 			}
 			f.Close()
+
+			// OpenFile keeps the mode of an existing file
+			if err := os.Chmod(target, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-func runInitialSteamCMDUpdate(steamcmdPath string) error {
-	// Run steamcmd +quit to trigger the initial update
-	cmd := exec.Command(steamcmdPath, "+quit")
-	cmd.Dir = filepath.Dir(steamcmdPath)
+// initialUpdateRetries is how often an initial update that didn't complete
+// is run again
+const initialUpdateRetries = 3
+
+// initialUpdateBackoff paces the resumed initial updates. Tests swap it for
+// one that doesn't wait.
+var initialUpdateBackoff = func() retry.Backoff {
+	return backoff.New(initialUpdateRetries)
+}
 
-	// Capture output for verbose mode
-	var outputBuf strings.Builder
-	cmd.Stdout = &outputBuf
-	cmd.Stderr = &outputBuf
+// errIncompleteUpdate is an initial update that stopped before SteamCMD
+// loaded the Steam API, e.g. because it was interrupted or the connection
+// dropped
+var errIncompleteUpdate = errors.New("SteamCMD exited before loading the Steam API")
 
-	err := cmd.Run()
+// runInitialSteamCMDUpdate runs SteamCMD once so it updates itself, which
+// takes a while on the first run. SteamCMD picks an interrupted update up
+// where it stopped, so an update that didn't get as far as loading the Steam
+// API is run again.
+func runInitialSteamCMDUpdate(steamcmdPath string) error {
+	var attempt int
+	var output string
+	err := retry.Do(context.Background(), initialUpdateBackoff(), func(ctx context.Context) error {
+		attempt++
+		if attempt > 1 {
+			fmt.Printf("%s Initial update incomplete; resuming it (attempt %d/%d)...\n", iconWarn, attempt, initialUpdateRetries+1)
+		}
+
+		var err error
+		output, err = runSteamCMDUpdate(ctx, steamcmdPath)
+		if strings.Contains(output, "Loading Steam API...OK") {
+			// SteamCMD may exit non-zero after restarting itself; the update is done
+			return nil
+		}
+		if err == nil {
+			err = errIncompleteUpdate
+		}
+		return retry.RetryableError(err)
+	})
 	if err != nil {
-		if viper.GetBool("verbose") {
-			fmt.Printf("SteamCMD output:\n%s\n", outputBuf.String())
+		// Verbose mode already showed everything
+		if !viper.GetBool("verbose") {
+			fmt.Printf("SteamCMD output:\n%s\n", output)
 		}
-		return fmt.Errorf("initial update failed: %w", err)
+		return fmt.Errorf("initial update failed after %d attempts: %w", attempt, err)
 	}
 
-	// Check if the output indicates successful update
-	output := outputBuf.String()
-	if strings.Contains(output, "Loading Steam API...OK") {
-		fmt.Println("Initial SteamCMD update completed successfully")
-		return nil
+	fmt.Println("Initial SteamCMD update completed successfully")
+	return nil
+}
+
+// runSteamCMDUpdate runs steamcmd +quit, printing its output live in verbose
+// mode, and returns the last lines of output
+func runSteamCMDUpdate(ctx context.Context, steamcmdPath string) (string, error) {
+	cmd := exec.CommandContext(ctx, steamcmdPath, "+quit")
+	cmd.Dir = filepath.Dir(steamcmdPath)
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		return "", err
 	}
 
-	if viper.GetBool("verbose") {
-		fmt.Printf("SteamCMD output:\n%s\n", output)
+	waitErr := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		waitErr <- err
+	}()
+
+	verbose := viper.GetBool("verbose")
+	var tail []string
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if verbose {
+			fmt.Printf("  steamcmd> %s\n", line)
+		}
+		tail = append(tail, line)
+		if len(tail) > 20 {
+			tail = tail[1:]
+		}
 	}
+	io.Copy(io.Discard, pr)
 
-	return nil
+	return strings.Join(tail, "\n"), <-waitErr
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+)
+
+func TestExtractPath(t *testing.T) {
+	dest := t.TempDir()
+
+	tests := []struct {
+		name string
+		want string // Relative to dest; empty when the entry must be rejected
+	}{
+		{"steamcmd.sh", "steamcmd.sh"},
+		{"linux32/steamcmd", filepath.Join("linux32", "steamcmd")},
+		{`linux32\steamcmd`, filepath.Join("linux32", "steamcmd")},
+		{"./steamcmd.sh", "steamcmd.sh"},
+		{"..hidden", "..hidden"},
+		{"a/../b", "b"},
+		{"../evil", ""},
+		{"a/../../evil", ""},
+		{`..\evil`, ""},
+		{`a\..\..\evil`, ""},
+		{`..\/evil`, ""},
+		{"/etc/passwd", ""},
+		{`\evil`, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := extractPath(dest, tt.name)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("extractPath(%q) = %q, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != filepath.Join(dest, tt.want) {
+			t.Errorf("extractPath(%q) = %q, %v; want %q", tt.name, got, err, filepath.Join(dest, tt.want))
+		}
+	}
+}
+
+func TestExtractRejectsEscapingEntries(t *testing.T) {
+	for _, name := range []string{"../evil.txt", `..\evil.txt`, "ok/../../evil.txt"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			dest := filepath.Join(dir, "dest")
+
+			// Zip
+			zipPath := filepath.Join(dir, "bad.zip")
+			zf, err := os.Create(zipPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zw := zip.NewWriter(zf)
+			w, _ := zw.Create(name)
+			w.Write([]byte("pwned"))
+			zw.Close()
+			zf.Close()
+
+			if err := extractZip(zipPath, dest); err == nil {
+				t.Errorf("extractZip() accepted entry %q", name)
+			}
+
+			// Tar
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 5, Typeflag: tar.TypeReg})
+			tw.Write([]byte("pwned"))
+			tw.Close()
+
+			if err := extractTar(&buf, dest); err == nil {
+				t.Errorf("extractTar() accepted entry %q", name)
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+				t.Errorf("entry %q was written outside the destination", name)
+			}
+		})
+	}
+}
+
+func TestRunInitialSteamCMDUpdateResumes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as SteamCMD")
+	}
+
+	// The first run stops mid-update, like an interrupted install
+	dir := t.TempDir()
+	script := filepath.Join(dir, "steamcmd.sh")
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+if [ ! -f started ]; then touch started; echo "[ 40%] Downloading update"; exit 1; fi
+echo "[100%] Download complete."
+echo "Loading Steam API...OK"
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(b func() retry.Backoff) { initialUpdateBackoff = b }(initialUpdateBackoff)
+	initialUpdateBackoff = func() retry.Backoff {
+		return retry.WithMaxRetries(initialUpdateRetries, retry.NewConstant(time.Millisecond))
+	}
+
+	if err := runInitialSteamCMDUpdate(script); err != nil {
+		t.Fatalf("runInitialSteamCMDUpdate() unexpected error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "started")); err != nil {
+		t.Errorf("SteamCMD wasn't run in its own directory: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// latestLinkName is the stable name pointing at an item's most recent download
+const latestLinkName = "latest"
+
+// latestPointerFile is written when neither a symlink nor a junction can be created
+const latestPointerFile = "latest.txt"
+
+// updateLatestLink points itemDir/latest at the version subdirectory and returns
+// the path of the link. It prefers a relative symlink, swapped in atomically; on
+// Windows without symlink privileges it falls back to a directory junction, and
+// as a last resort to a latest.txt file containing the version name.
+func updateLatestLink(itemDir, version string) (string, error) {
+	linkPath := filepath.Join(itemDir, latestLinkName)
+
+	// Create the new link under a temporary name and rename it over the old one
+	tmpLink := linkPath + ".tmp"
+	os.Remove(tmpLink)
+	if err := os.Symlink(version, tmpLink); err == nil {
+		if err := os.Rename(tmpLink, linkPath); err == nil {
+			return linkPath, nil
+		}
+		os.Remove(tmpLink)
+
+		// Renaming over an existing link fails on some platforms
+		if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if err := os.Symlink(version, linkPath); err != nil {
+			return "", err
+		}
+		return linkPath, nil
+	}
+
+	if runtime.GOOS == "windows" {
+		target := filepath.Join(itemDir, version)
+		os.Remove(linkPath)
+		if err := exec.Command("cmd", "/c", "mklink", "/J", linkPath, target).Run(); err == nil {
+			return linkPath, nil
+		}
+	}
+
+	pointerPath := filepath.Join(itemDir, latestPointerFile)
+	if err := os.WriteFile(pointerPath, []byte(version+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", pointerPath, err)
+	}
+	return pointerPath, nil
+}
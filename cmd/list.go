@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/webapi"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List downloaded workshop items",
+	Long: `List the workshop items downloaded with SteamCMD, grouped by game.
+
+Use --app-id to only list the items of a single game, and --format to pick
+between a table, CSV (one row per item, stable column order) or JSON.
+Titles and game names aren't stored locally; --details looks them up online.
+
+Examples:
+  workshop list
+  workshop list --app-id 108600
+  workshop list --format csv --details > library.csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listItems()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().String("app-id", "", "Only list items of this Steam App ID")
+	listCmd.Flags().String("format", "table", "Output format: table, csv or json")
+	listCmd.Flags().Bool("details", false, "Look up titles and game names with the Steam Web API")
+	bindFlag("list_app_id", listCmd.Flags().Lookup("app-id"))
+	bindFlag("list_format", listCmd.Flags().Lookup("format"))
+	bindFlag("list_details", listCmd.Flags().Lookup("details"))
+}
+
+// listEntry is one downloaded item as reported by the list command
+type listEntry struct {
+	AppID       string    `json:"app_id"`
+	WorkshopID  string    `json:"workshop_id"`
+	Title       string    `json:"title"`
+	Game        string    `json:"game"`
+	SizeBytes   int64     `json:"size_bytes"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// listCSVHeader is the CSV column order; keep it stable so exports diff cleanly
+var listCSVHeader = []string{"app_id", "workshop_id", "title", "game", "size_bytes", "last_updated"}
+
+func listItems() error {
+	appID := viper.GetString("list_app_id")
+	if appID != "" {
+		if err := ValidateAppID(appID); err != nil {
+			return err
+		}
+	}
+
+	format := viper.GetString("list_format")
+	if format != "table" && format != "csv" && format != "json" {
+		return fmt.Errorf("invalid --format %q: must be table, csv or json", format)
+	}
+
+	client, err := newSteamCMDClient(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to create SteamCMD client: %w", err)
+	}
+
+	items, err := downloadedItems(client, appID)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]listEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, listEntry{
+			AppID:       item.AppID,
+			WorkshopID:  item.WorkshopID,
+			SizeBytes:   item.SizeBytes,
+			LastUpdated: item.LastDownloaded,
+		})
+	}
+
+	if viper.GetBool("list_details") && len(entries) > 0 {
+		addListDetails(entries)
+	}
+
+	switch format {
+	case "csv":
+		return writeListCSV(os.Stdout, entries)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		if appID != "" {
+			fmt.Printf("No downloaded workshop items found for app %s.\n", appID)
+		} else {
+			fmt.Println("No downloaded workshop items found.")
+		}
+		return nil
+	}
+
+	var total int64
+	currentApp := ""
+	for _, entry := range entries {
+		if entry.AppID != currentApp {
+			currentApp = entry.AppID
+			if entry.Game != "" {
+				fmt.Printf("App %s (%s):\n", currentApp, entry.Game)
+			} else {
+				fmt.Printf("App %s:\n", currentApp)
+			}
+		}
+
+		total += entry.SizeBytes
+		fmt.Printf("  %-12s %10s  %s\n", entry.WorkshopID, formatBytes(entry.SizeBytes), entry.Title)
+	}
+
+	fmt.Printf("\n%d item(s), %s\n", len(entries), formatBytes(total))
+	return nil
+}
+
+// addListDetails fills in titles and game names from the Steam APIs. Lookups
+// are best effort: failures are reported on stderr and leave the fields empty.
+func addListDetails(entries []listEntry) {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.WorkshopID
+	}
+
+	details, err := webapi.GetPublishedFileDetailsBatch(ids)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Could not look up item titles: %v\n", iconWarn, err)
+	}
+
+	games := make(map[string]string)
+	for i := range entries {
+		if d, ok := details[entries[i].WorkshopID]; ok {
+			entries[i].Title = d.Title
+		}
+
+		appID := entries[i].AppID
+		if _, ok := games[appID]; !ok {
+			name, err := webapi.GetAppName(appID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s Could not look up the name of app %s: %v\n", iconWarn, appID, err)
+			}
+			games[appID] = name
+		}
+		entries[i].Game = games[appID]
+	}
+}
+
+// writeListCSV writes the entries as CSV with a header row
+func writeListCSV(w io.Writer, entries []listEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(listCSVHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		lastUpdated := ""
+		if !entry.LastUpdated.IsZero() {
+			lastUpdated = entry.LastUpdated.Format(time.RFC3339)
+		}
+
+		record := []string{
+			entry.AppID,
+			entry.WorkshopID,
+			entry.Title,
+			entry.Game,
+			strconv.FormatInt(entry.SizeBytes, 10),
+			lastUpdated,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// downloadedItems returns the downloaded workshop items sorted by app and
+// workshop ID, only those of appID when it's set
+func downloadedItems(client *steamcmd.Client, appID string) ([]steamcmd.DownloadedItem, error) {
+	all, err := client.ListItems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list downloaded items: %w", err)
+	}
+
+	if appID == "" {
+		return all, nil
+	}
+
+	var items []steamcmd.DownloadedItem
+	for _, item := range all {
+		if item.AppID == appID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
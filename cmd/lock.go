@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+var (
+	lockMu   sync.Mutex
+	heldLock *steamcmd.Lock
+)
+
+// lockSteamCMD takes the SteamCMD directory lock for the rest of the run.
+// Every command that runs SteamCMD or touches its cache calls it first.
+func lockSteamCMD() error {
+	lockMu.Lock()
+	defer lockMu.Unlock()
+
+	if heldLock != nil {
+		return nil
+	}
+
+	dir := viper.GetString("steamcmd_dir")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		// Nothing to protect yet; the command reports the missing install
+		return nil
+	}
+
+	lock, err := steamcmd.AcquireLock(dir, viper.GetDuration("lock_wait"))
+	if err != nil {
+		return err
+	}
+	heldLock = lock
+	return nil
+}
+
+// releaseSteamCMDLock releases the SteamCMD directory lock, if held
+func releaseSteamCMDLock() {
+	lockMu.Lock()
+	defer lockMu.Unlock()
+
+	if heldLock == nil {
+		return
+	}
+	if err := heldLock.Release(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to remove lock file: %v\n", iconWarn, err)
+	}
+	heldLock = nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/logfile"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// logger writes the tool's own activity log to --log-file, one JSON object
+// per line. Without --log-file it discards everything.
+var logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+// logWriter is the open --log-file, closed when the run ends
+var logWriter *logfile.Writer
+
+// openLog starts the --log-file log at --log-level, rotating it at
+// --log-max-size
+func openLog() error {
+	path := viper.GetString("log_file")
+	if path == "" {
+		return nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(viper.GetString("log_level"))); err != nil {
+		return fmt.Errorf("invalid --log-level %q: must be debug, info, warn or error", viper.GetString("log_level"))
+	}
+	maxSize, err := parseByteSize(viper.GetString("log_max_size"))
+	if err != nil {
+		return fmt.Errorf("--log-max-size: %w", err)
+	}
+
+	w, err := logfile.Open(path, maxSize, viper.GetInt("log_max_backups"))
+	if err != nil {
+		return err
+	}
+	logWriter = w
+
+	// The PID tells apart runs that share a log file
+	logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})).With("pid", os.Getpid())
+	logger.Info("run started", "args", os.Args[1:], "version", buildVersion)
+	return nil
+}
+
+// logRunFinished records the outcome of the command that ran and closes the log
+func logRunFinished(cmd *cobra.Command, err error, elapsed time.Duration) {
+	if logWriter == nil {
+		return
+	}
+
+	attrs := []any{"seconds", elapsed.Seconds()}
+	if cmd != nil {
+		attrs = append(attrs, "command", strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "))
+	}
+	if err != nil {
+		logger.Error("run failed", append(attrs, "error", err.Error())...)
+	} else {
+		logger.Info("run finished", attrs...)
+	}
+
+	logWriter.Close()
+	logWriter = nil
+}
+
+// logResult records the outcome of one item
+func logResult(result *downloadResult, elapsed time.Duration) {
+	attrs := []any{
+		"app_id", result.AppID,
+		"workshop_id", result.WorkshopID,
+		"status", result.Status,
+		"seconds", elapsed.Seconds(),
+	}
+	if result.Path != "" {
+		attrs = append(attrs, "path", result.Path, "size_bytes", result.SizeBytes)
+	}
+	if result.Account != "" {
+		attrs = append(attrs, "account", result.Account)
+	}
+
+	if result.Status == statusFailed {
+		logger.Error("item failed", append(attrs, "error", result.Error, "error_code", result.ErrorCode)...)
+		return
+	}
+	logger.Info("item "+result.Status, attrs...)
+}
+
+// logEnabled reports whether the log records messages at level
+func logEnabled(level slog.Level) bool {
+	return logger.Enabled(context.Background(), level)
+}
@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 // loginCmd represents the login command
@@ -25,25 +28,176 @@ After running this command:
 4. Enter Steam Guard code if requested
 5. Type: quit
 
-Your authentication will be stored for future downloads.`,
+Your authentication will be stored for future downloads.
+
+With --username the login runs without SteamCMD's console: the password is
+read from the WORKSHOP_PASSWORD environment variable, or asked for without
+echo in a terminal, and a Steam Guard code is fetched from the mailbox
+configured under steam_guard.imap, or asked for when there is none or it
+fails. A password in the config file is refused, since it would sit there in
+plain text.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := lockSteamCMD(); err != nil {
+			return err
+		}
+		if username := viper.GetString("login_username"); username != "" {
+			return loginWithPassword(username)
+		}
 		return launchInteractiveSteamCMD()
 	},
 }
 
+// loginStatusCmd represents the login status command
+var loginStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check whether SteamCMD's cached credentials still work",
+	Long: `Log in with SteamCMD's cached credentials, without a password, to check
+that downloads with --username will work.
+
+The username defaults to the configured username, then to the account
+SteamCMD logged in with last. The command fails when the credentials
+can't be used, so scripts can check it before a batch.
+
+Examples:
+  workshop login status
+  workshop login status --username myuser`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := lockSteamCMD(); err != nil {
+			return err
+		}
+		return checkLoginStatus()
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(loginCmd)
+	loginCmd.AddCommand(loginStatusCmd)
+
+	loginCmd.Flags().StringP("username", "u", "", "log in as this user without SteamCMD's console, fetching the Steam Guard code from steam_guard.imap when configured")
+	bindFlag("login_username", loginCmd.Flags().Lookup("username"))
+
+	loginStatusCmd.Flags().StringP("username", "u", "", "Steam username to check (default: configured username, then the last login)")
+	bindFlag("login_status_username", loginStatusCmd.Flags().Lookup("username"))
+}
+
+func checkLoginStatus() error {
+	client, err := newSteamCMDClient(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to create SteamCMD client: %w", err)
+	}
+
+	logins, err := client.CachedLogins()
+	if err != nil {
+		fmt.Printf("%s %v\n", iconWarn, err)
+	}
+
+	username := viper.GetString("login_status_username")
+	if username == "" {
+		username = viper.GetString("username")
+	}
+	if username == "" && len(logins) > 0 {
+		username = logins[0].Username
+	}
+	if username == "" {
+		fmt.Printf("%s SteamCMD has no cached login\n", iconErr)
+		fmt.Printf("%s Run 'workshop login' to log in\n", iconTip)
+		return fmt.Errorf("not logged in: %w", steamcmd.ErrNotLoggedOn)
+	}
+
+	fmt.Printf("Checking cached credentials for %s...\n", username)
+	err = client.CheckCachedLogin(username)
+
+	var last time.Time
+	for _, login := range logins {
+		if login.Username == username {
+			last = login.LastLogin
+		}
+	}
+
+	if err != nil {
+		fmt.Printf("%s Cached credentials for %s can't be used: %v\n", iconErr, username, err)
+		if errors.Is(err, steamcmd.ErrNotLoggedOn) || errors.Is(err, steamcmd.ErrLoginFailed) {
+			fmt.Printf("%s Run 'workshop login' to log in again\n", iconTip)
+		}
+		return err
+	}
+
+	fmt.Printf("%s Logged in as %s with cached credentials\n", iconOK, username)
+	if !last.IsZero() {
+		days := int(time.Since(last).Hours() / 24)
+		fmt.Printf("   Last login: %s (%d day(s) ago)\n", last.Local().Format("2006-01-02 15:04"), days)
+	}
+	fmt.Println("   Steam drops cached credentials after a password change, a Steam Guard reset or")
+	fmt.Println("   a long time unused; run this again before large batches and 'workshop login' if it fails.")
+	return nil
+}
+
+// loginWithPassword logs in as username with the password from the
+// environment or the user, getting a Steam Guard code from the configured
+// mailbox or the user when needed
+func loginWithPassword(username string) error {
+	password, err := loginPassword(username)
+	if err != nil {
+		return err
+	}
+
+	client, err := newSteamCMDClient(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to create SteamCMD client: %w", err)
+	}
+	client.GuardCodes = guardCodeSource()
+
+	if err := client.InteractiveLogin(username, password); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Logged in as %s; downloads with --username %s now use the cached credentials\n", iconOK, username, username)
+	return nil
+}
+
+// loginPassword returns the password in WORKSHOP_PASSWORD, or asks for it
+// without echo when stdin is a terminal. It's never read from the config
+// file, which would keep it in plain text.
+func loginPassword(username string) (string, error) {
+	if viper.InConfig("password") {
+		return "", fmt.Errorf("the config file sets 'password', which keeps your Steam password in plain text; remove it and set %s or enter the password when asked", envName("password"))
+	}
+
+	if password := os.Getenv(envName("password")); password != "" {
+		return password, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("no password for %s: set %s, or run 'workshop login' without --username to log in in SteamCMD's console", username, envName("password"))
+	}
+
+	fmt.Printf("Steam password for %s: ", username)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the password: %w", err)
+	}
+	if len(password) == 0 {
+		return "", fmt.Errorf("no password entered for %s", username)
+	}
+	return string(password), nil
 }
 
 func launchInteractiveSteamCMD() error {
+	// Without a terminal SteamCMD's prompts can't be answered and the login
+	// fails without saying so
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return fmt.Errorf("login requires an interactive terminal: SteamCMD prompts for the password and Steam Guard code. " +
+			"Run 'workshop login' once in a terminal on this machine (or with the same --steamcmd-dir); " +
+			"downloads with --username then reuse the cached credentials without one, and 'workshop login status' checks them")
+	}
+
 	// Create SteamCMD client to get the path
-	steamcmdDir := viper.GetString("steamcmd_dir")
-	client, err := steamcmd.NewClient(steamcmdDir)
+	client, err := newSteamCMDClient(os.Stdout)
 	if err != nil {
 		return fmt.Errorf("failed to create SteamCMD client: %w", err)
 	}
 
-	fmt.Println("🚀 Launching SteamCMD for interactive login...")
+	fmt.Printf("%s Launching SteamCMD for interactive login...\n", iconStart)
 	fmt.Println()
 	fmt.Println("Instructions:")
 	fmt.Println("1. At the Steam> prompt, type: login yourusername")
@@ -68,7 +222,7 @@ func launchInteractiveSteamCMD() error {
 	}
 
 	fmt.Println()
-	fmt.Println("✅ SteamCMD session completed!")
+	fmt.Printf("%s SteamCMD session completed!\n", iconOK)
 	fmt.Println("If you logged in successfully, you can now download workshop items without authentication.")
 	return nil
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/manifest"
+	"github.com/spf13/viper"
+)
+
+// lockedItems collects the items of this run for the --manifest-out lockfile
+var (
+	lockedMu    sync.Mutex
+	lockedItems []manifest.Item
+)
+
+// recordLockedItem adds an item that is now in place to the lockfile, with
+// whatever version information SteamCMD recorded for it
+func recordLockedItem(w io.Writer, result *downloadResult) {
+	if viper.GetString("manifest_out") == "" || result.Path == "" {
+		return
+	}
+
+	item := manifest.Item{
+		AppID:      result.AppID,
+		WorkshopID: result.WorkshopID,
+		Title:      result.Title,
+		SizeBytes:  result.SizeBytes,
+	}
+
+	client, err := newSteamCMDClient(w)
+	if err == nil {
+		if installed, err := client.GetInstalledItem(result.AppID, result.WorkshopID); err == nil {
+			item.ManifestID = installed.ManifestID
+			if size, err := strconv.ParseInt(installed.SizeBytes, 10, 64); err == nil && item.SizeBytes == 0 {
+				item.SizeBytes = size
+			}
+		}
+	}
+
+	if sum, err := manifest.HashDir(result.Path); err == nil {
+		item.SHA256 = sum
+	} else {
+		fmt.Fprintf(w, "%s Could not hash %s for the lockfile: %v\n", iconWarn, result.Path, err)
+	}
+
+	lockedMu.Lock()
+	defer lockedMu.Unlock()
+
+	// Keep one entry per (app, workshop item) pair, the latest one
+	for i, locked := range lockedItems {
+		if locked.AppID == item.AppID && locked.WorkshopID == item.WorkshopID {
+			lockedItems[i] = item
+			return
+		}
+	}
+	lockedItems = append(lockedItems, item)
+}
+
+// withManifestOut runs fn and then writes the items recorded meanwhile to the
+// --manifest-out lockfile, even when some of them failed
+func withManifestOut(fn func() error) error {
+	err := fn()
+
+	path := viper.GetString("manifest_out")
+	if path == "" {
+		return err
+	}
+
+	lockedMu.Lock()
+	items := append([]manifest.Item(nil), lockedItems...)
+	lockedMu.Unlock()
+
+	// Sorted for a stable, diff-friendly lockfile
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].AppID != items[j].AppID {
+			return items[i].AppID < items[j].AppID
+		}
+		return items[i].WorkshopID < items[j].WorkshopID
+	})
+
+	m := &manifest.Manifest{Items: items}
+	if saveErr := m.Save(path); saveErr != nil {
+		if err == nil {
+			err = saveErr
+		}
+		return err
+	}
+
+	fmt.Fprintf(humanOutput(), "Wrote lockfile with %d item(s) to %s\n", len(items), path)
+	return err
+}
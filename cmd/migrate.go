@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --from <old steamcmd dir> [--to <new steamcmd dir>]",
+	Short: "Move downloaded workshop items to another SteamCMD directory",
+	Long: `Move the downloaded workshop items of one SteamCMD directory to another,
+e.g. after changing steamcmd_dir. --to defaults to the configured directory.
+
+Items already present at the destination are skipped. SteamCMD's workshop
+state (appworkshop_<appid>.acf) is moved along unless the destination has its
+own for that app. When the items have to be copied, between filesystems or
+with --copy, the destination must have enough free space.
+
+Examples:
+  workshop migrate --from ~/old/steamcmd
+  workshop migrate --from ~/old/steamcmd --to /mnt/games/steamcmd --copy`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrateItems()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().String("from", "", "SteamCMD directory to move the items from")
+	migrateCmd.Flags().String("to", "", "SteamCMD directory to move the items to (default: configured steamcmd_dir)")
+	migrateCmd.Flags().Bool("copy", false, "Copy the items instead of moving them")
+	migrateCmd.MarkFlagRequired("from")
+	bindFlag("migrate_from", migrateCmd.Flags().Lookup("from"))
+	bindFlag("migrate_to", migrateCmd.Flags().Lookup("to"))
+	bindFlag("migrate_copy", migrateCmd.Flags().Lookup("copy"))
+}
+
+// migrateItem is a downloaded item to move
+type migrateItem struct {
+	steamcmd.DownloadedItem
+	dst string
+}
+
+func migrateItems() error {
+	from := viper.GetString("migrate_from")
+	to := viper.GetString("migrate_to")
+	if to == "" {
+		to = viper.GetString("steamcmd_dir")
+	}
+	copyItems := viper.GetBool("migrate_copy")
+
+	fromAbs, err := filepath.Abs(from)
+	if err != nil {
+		return err
+	}
+	toAbs, err := filepath.Abs(to)
+	if err != nil {
+		return err
+	}
+	if fromAbs == toAbs {
+		return fmt.Errorf("--from and --to are the same directory: %s", fromAbs)
+	}
+
+	// Neither side may be in use while items move
+	for _, dir := range []string{fromAbs, toAbs} {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		lock, err := steamcmd.AcquireLock(dir, viper.GetDuration("lock_wait"))
+		if err != nil {
+			return fmt.Errorf("%s: %w", dir, err)
+		}
+		defer lock.Release()
+	}
+
+	// The directories only need the workshop layout, not a SteamCMD install
+	source := &steamcmd.Client{WorkingDir: fromAbs}
+	dest := &steamcmd.Client{WorkingDir: toAbs}
+
+	items, err := source.ListItems()
+	if err != nil {
+		return fmt.Errorf("failed to list items in %s: %w", fromAbs, err)
+	}
+	if len(items) == 0 {
+		fmt.Printf("No downloaded workshop items found in %s.\n", fromAbs)
+		return nil
+	}
+
+	var pending []migrateItem
+	var skipped int
+	var needed int64
+	for _, item := range items {
+		dst := filepath.Join(dest.GetWorkshopPath(), item.AppID, item.WorkshopID)
+		if _, err := os.Stat(dst); err == nil {
+			fmt.Printf("%s %s/%s already exists at the destination, skipping\n", iconOK, item.AppID, item.WorkshopID)
+			skipped++
+			continue
+		}
+		pending = append(pending, migrateItem{DownloadedItem: item, dst: dst})
+		needed += item.SizeBytes
+	}
+
+	copyOpts, err := copyOptionsFromConfig(os.Stdout)
+	if err != nil {
+		return err
+	}
+	if err := copyOpts.MkdirAll(dest.GetWorkshopPath(), 0755); err != nil {
+		return fmt.Errorf("failed to create destination: %w", err)
+	}
+
+	// A rename within a filesystem needs no space; anything else is a full copy
+	if copyItems || !sameFilesystem(source.GetWorkshopPath(), dest.GetWorkshopPath()) {
+		free, err := freeSpace(dest.GetWorkshopPath())
+		if err != nil {
+			fmt.Printf("%s Could not check free space at the destination: %v\n", iconWarn, err)
+		} else if uint64(needed) > free {
+			return fmt.Errorf("not enough space in %s: %s needed, %s free", toAbs, formatBytes(needed), formatBytes(int64(free)))
+		}
+	}
+
+	verb := "Moving"
+	if copyItems {
+		verb = "Copying"
+	}
+	fmt.Printf("%s %d item(s), %s, from %s to %s\n", verb, len(pending), formatBytes(needed), fromAbs, toAbs)
+
+	var failed int
+	apps := make(map[string]bool)
+	for _, item := range pending {
+		if err := copyOpts.MkdirAll(filepath.Dir(item.dst), 0755); err == nil {
+			if copyItems {
+				err = fsutil.CopyDir(item.Path, item.dst, copyOpts)
+			} else {
+				err = fsutil.MoveDir(item.Path, item.dst, copyOpts)
+			}
+		}
+		if err != nil {
+			fmt.Printf("%s %s/%s: %v\n", iconErr, item.AppID, item.WorkshopID, err)
+			failed++
+			continue
+		}
+		apps[item.AppID] = true
+	}
+
+	for appID := range apps {
+		if err := migrateWorkshopState(fromAbs, toAbs, appID, copyItems); err != nil {
+			fmt.Printf("%s %v\n", iconWarn, err)
+		}
+	}
+
+	fmt.Printf("%s Migrated %d item(s), skipped %d, failed %d\n", iconOK, len(pending)-failed, skipped, failed)
+	if viper.GetString("steamcmd_dir") != to {
+		fmt.Printf("%s Point steamcmd_dir at %s to use them: workshop --steamcmd-dir %s list\n", iconTip, toAbs, toAbs)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d item(s) could not be migrated", failed)
+	}
+	return nil
+}
+
+// migrateWorkshopState carries an app's appworkshop_<appid>.acf over, so
+// SteamCMD keeps knowing the versions of the migrated items. A destination
+// that already has state for the app keeps its own.
+func migrateWorkshopState(from, to, appID string, copyState bool) error {
+	name := fmt.Sprintf("appworkshop_%s.acf", appID)
+	src := filepath.Join(from, "steamapps", "workshop", name)
+	dst := filepath.Join(to, "steamapps", "workshop", name)
+
+	if _, err := os.Stat(src); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists at the destination; SteamCMD will update it on the next download", name)
+	}
+
+	if !copyState && os.Rename(src, dst) == nil {
+		return nil
+	}
+	if err := fsutil.CopyFile(src, dst, fsutil.Options{}); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", name, err)
+	}
+	if !copyState {
+		os.Remove(src)
+	}
+	return nil
+}
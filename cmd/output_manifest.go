@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/manifest"
+	"github.com/spf13/viper"
+)
+
+// outputFile is one file written to an output directory, for --output-manifest
+type outputFile struct {
+	Path   string // Slash-separated, relative to the output directory
+	Size   int64
+	SHA256 string // Empty without --output-manifest-hashes
+}
+
+// outputFiles collects the files written by this run, by path
+var (
+	outputFilesMu sync.Mutex
+	outputFiles   = map[string]outputFile{}
+)
+
+// recordOutputFiles adds the files under itemDir to the --output-manifest,
+// with paths relative to baseDir. Items written to several output
+// directories are listed once.
+func recordOutputFiles(baseDir, itemDir string) error {
+	if viper.GetString("output_manifest") == "" {
+		return nil
+	}
+
+	var paths []string
+	err := filepath.WalkDir(itemDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list output files: %w", err)
+	}
+
+	return recordOutputPaths(baseDir, paths)
+}
+
+// recordOutputPaths adds the given files to the --output-manifest, with paths
+// relative to baseDir
+func recordOutputPaths(baseDir string, paths []string) error {
+	if viper.GetString("output_manifest") == "" {
+		return nil
+	}
+	hashes := viper.GetBool("output_manifest_hashes")
+
+	files := make([]outputFile, 0, len(paths))
+	for _, path := range paths {
+		rel, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to list output files: %w", err)
+		}
+
+		file := outputFile{Path: filepath.ToSlash(rel), Size: info.Size()}
+		if hashes {
+			if file.SHA256, err = manifest.HashFile(path); err != nil {
+				return err
+			}
+		}
+		files = append(files, file)
+	}
+
+	outputFilesMu.Lock()
+	defer outputFilesMu.Unlock()
+	for _, file := range files {
+		outputFiles[file.Path] = file
+	}
+	return nil
+}
+
+// withOutputManifest runs fn and then writes the files recorded meanwhile to
+// the --output-manifest file, even when some items failed
+func withOutputManifest(fn func() error) error {
+	err := fn()
+
+	path := viper.GetString("output_manifest")
+	if path == "" {
+		return err
+	}
+
+	outputFilesMu.Lock()
+	files := make([]outputFile, 0, len(outputFiles))
+	for _, file := range outputFiles {
+		files = append(files, file)
+	}
+	outputFilesMu.Unlock()
+
+	// Sorted for a stable, diff-friendly manifest
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	if writeErr := writeOutputManifest(path, files); writeErr != nil {
+		if err == nil {
+			err = writeErr
+		}
+		return err
+	}
+
+	fmt.Fprintf(humanOutput(), "Wrote output manifest with %d file(s) to %s\n", len(files), path)
+	return err
+}
+
+// writeOutputManifest writes one tab-separated "path size sha256" line per
+// file, the hash being "-" when it wasn't computed
+func writeOutputManifest(path string, files []outputFile) error {
+	var b strings.Builder
+	b.WriteString("# path\tsize\tsha256\n")
+	for _, file := range files {
+		sum := file.SHA256
+		if sum == "" {
+			sum = "-"
+		}
+		fmt.Fprintf(&b, "%s\t%d\t%s\n", file.Path, file.Size, sum)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write output manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// parseOutputCmd classifies saved SteamCMD output. It's hidden: it exists to
+// reproduce parsing bugs from the output users send in.
+var parseOutputCmd = &cobra.Command{
+	Use:   "parse-output <file>",
+	Short: "Classify saved SteamCMD output the way a download would",
+	Long: `Run the SteamCMD output parser over a saved log or captured output and
+print how a download would have classified it: success or failure, the
+downloaded path and size, the account, the error code and whether the
+download would have been retried. Use - to read from stdin.
+
+Examples:
+  workshop parse-output steamcmd-output.txt
+  steamcmd +login anonymous +workshop_download_item 108600 2503622437 +quit | workshop parse-output - --json`,
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return parseOutputFile(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(parseOutputCmd)
+
+	parseOutputCmd.Flags().Bool("json", false, "Print the classification as JSON")
+	bindFlag("parse_output_json", parseOutputCmd.Flags().Lookup("json"))
+}
+
+// outputClassification is the report of the parse-output command
+type outputClassification struct {
+	Success   bool   `json:"success"`
+	Path      string `json:"path,omitempty"`
+	SizeBytes int64  `json:"size_bytes"`
+	Account   string `json:"account,omitempty"`
+	ErrorMsg  string `json:"error_msg,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Retryable bool   `json:"retryable"`
+}
+
+func parseOutputFile(path string) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to read SteamCMD output: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// Retries depend on --retry-mode, like in a real download
+	mode := viper.GetString("retry_mode")
+	if mode != steamcmd.RetryLenient && mode != steamcmd.RetryStrict {
+		return fmt.Errorf("invalid --retry-mode %q: must be lenient or strict", mode)
+	}
+	client := &steamcmd.Client{RetryMode: mode}
+
+	parsed, err := client.ParseOutput(r)
+	if err != nil {
+		return fmt.Errorf("failed to read SteamCMD output: %w", err)
+	}
+
+	item := parsed.Item
+	report := outputClassification{
+		Success:   item.Success,
+		Path:      item.PathToFile,
+		SizeBytes: item.SizeBytes,
+		Account:   item.Account,
+		ErrorMsg:  item.ErrorMsg,
+		Retryable: parsed.Retryable,
+	}
+	if parsed.Err != nil {
+		report.Error = parsed.Err.Error()
+		report.ErrorCode = errorCode(parsed.Err)
+	}
+
+	if viper.GetBool("parse_output_json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	if report.Success {
+		fmt.Printf("%s Success\n", iconOK)
+		fmt.Printf("Path: %s\n", report.Path)
+		fmt.Printf("Size: %s\n", formatBytes(report.SizeBytes))
+	} else {
+		fmt.Printf("%s Failure: %s\n", iconErr, report.ErrorMsg)
+		fmt.Printf("Error: %s\n", report.Error)
+		fmt.Printf("Error code: %s\n", report.ErrorCode)
+		fmt.Printf("Retried: %v\n", report.Retryable)
+	}
+	if report.Account != "" {
+		fmt.Printf("Account: %s\n", report.Account)
+	}
+	return nil
+}
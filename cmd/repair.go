@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/manifest"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+// The --only-missing-files manifest is read once per run
+var (
+	repairOnce    sync.Once
+	repairEntries map[string]outputFile
+	repairErr     error
+)
+
+// repairManifest returns the files listed in the --only-missing-files
+// manifest by path, or nil when no repair was asked for
+func repairManifest() (map[string]outputFile, error) {
+	path := viper.GetString("only_missing_files")
+	if path == "" {
+		return nil, nil
+	}
+
+	repairOnce.Do(func() {
+		var files []outputFile
+		files, repairErr = loadOutputManifest(path)
+		repairEntries = make(map[string]outputFile, len(files))
+		for _, file := range files {
+			repairEntries[file.Path] = file
+		}
+	})
+	return repairEntries, repairErr
+}
+
+// loadOutputManifest reads a file written by --output-manifest
+func loadOutputManifest(path string) ([]outputFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output manifest: %w", err)
+	}
+	defer f.Close()
+
+	var files []outputFile
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected \"path<TAB>size<TAB>sha256\", got %q", path, line, text)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("%s:%d: invalid size %q", path, line, fields[1])
+		}
+		// Repairs write to these paths, so they must stay inside the output
+		if !filepath.IsLocal(filepath.FromSlash(fields[0])) {
+			return nil, fmt.Errorf("%s:%d: path %q is not inside the output directory", path, line, fields[0])
+		}
+		file := outputFile{Path: fields[0], Size: size}
+		if fields[2] != "-" {
+			file.SHA256 = fields[2]
+		}
+		files = append(files, file)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read output manifest: %w", err)
+	}
+
+	return files, nil
+}
+
+// repairItemOutput copies only the files of the item that the manifest
+// expects under itemName but that are missing from outputDir, or differ
+// from the manifest in size, or in SHA-256 when it was recorded
+func repairItemOutput(w io.Writer, item *steamcmd.WorkshopItem, outputDir, itemName string, expected map[string]outputFile, opts fsutil.Options) error {
+	prefix := itemName + "/"
+
+	var listed, missing, mismatched, unavailable int
+	for path, want := range expected {
+		rel, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			continue
+		}
+		listed++
+
+		// Like archive entries, manifest paths must not lead out of the output or the download
+		dst, err := extractPath(outputDir, path)
+		if err != nil {
+			return fmt.Errorf("output manifest: %w", err)
+		}
+		src, err := extractPath(item.PathToFile, rel)
+		if err != nil {
+			return fmt.Errorf("output manifest: %w", err)
+		}
+
+		reason, err := outputFileDiffers(dst, want)
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			continue
+		}
+
+		if _, err := os.Stat(src); err != nil {
+			fmt.Fprintf(w, "%s %s is %s but not in the download; it can't be repaired\n", iconWarn, path, reason)
+			unavailable++
+			continue
+		}
+
+		if err := opts.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := fsutil.CopyFile(src, dst, opts); err != nil {
+			return fmt.Errorf("failed to repair %s: %w", path, err)
+		}
+		if reason == "missing" {
+			missing++
+		} else {
+			mismatched++
+		}
+	}
+
+	if listed == 0 {
+		return fmt.Errorf("the output manifest lists no files under %s", itemName)
+	}
+
+	fmt.Fprintf(w, "Repaired %d of %d files (%d missing, %d changed); %d intact\n",
+		missing+mismatched, listed, missing, mismatched, listed-missing-mismatched-unavailable)
+	if unavailable > 0 {
+		return fmt.Errorf("%d file(s) listed in the output manifest are not in the download", unavailable)
+	}
+	return nil
+}
+
+// outputFileDiffers returns why the file at path doesn't match the manifest
+// entry ("missing", "a different size" or "changed"), or "" when it matches
+func outputFileDiffers(path string, want outputFile) (string, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "missing", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.Size() != want.Size {
+		return "a different size", nil
+	}
+
+	if want.SHA256 != "" {
+		sum, err := manifest.HashFile(path)
+		if err != nil {
+			return "", err
+		}
+		if sum != want.SHA256 {
+			return "changed", nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+)
+
+func TestOutputManifestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "files.txt")
+	files := []outputFile{
+		{Path: "app_108600_workshop_1/mod.info", Size: 4, SHA256: "06271baf49532c879aa3c58b48671884bcc858f09197412d682750496c33e1e1"},
+		{Path: "app_108600_workshop_1/media/a b.pak", Size: 0},
+	}
+	if err := writeOutputManifest(path, files); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadOutputManifest(path)
+	if err != nil {
+		t.Fatalf("loadOutputManifest() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(got, files) {
+		t.Errorf("loadOutputManifest() = %v, want %v", got, files)
+	}
+}
+
+func TestRepairItemOutput(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	item := &steamcmd.WorkshopItem{PathToFile: src}
+	itemDir := filepath.Join(out, "app_108600_workshop_1")
+	os.MkdirAll(itemDir, 0755)
+
+	for name, content := range map[string]string{"intact.txt": "same", "missing.txt": "gone", "short.txt": "full"} {
+		os.WriteFile(filepath.Join(src, name), []byte(content), 0644)
+	}
+	os.WriteFile(filepath.Join(itemDir, "intact.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(itemDir, "short.txt"), []byte("ful"), 0644)
+
+	expected := map[string]outputFile{
+		"app_108600_workshop_1/intact.txt":  {Path: "app_108600_workshop_1/intact.txt", Size: 4},
+		"app_108600_workshop_1/missing.txt": {Path: "app_108600_workshop_1/missing.txt", Size: 4},
+		"app_108600_workshop_1/short.txt":   {Path: "app_108600_workshop_1/short.txt", Size: 4},
+		"app_108600_workshop_2/other.txt":   {Path: "app_108600_workshop_2/other.txt", Size: 1},
+	}
+
+	// Intact files must not be rewritten
+	before, _ := os.Stat(filepath.Join(itemDir, "intact.txt"))
+	if err := repairItemOutput(io.Discard, item, out, "app_108600_workshop_1", expected, fsutil.Options{}); err != nil {
+		t.Fatalf("repairItemOutput() unexpected error = %v", err)
+	}
+	after, _ := os.Stat(filepath.Join(itemDir, "intact.txt"))
+	if !os.SameFile(before, after) {
+		t.Error("intact.txt was rewritten")
+	}
+
+	for _, name := range []string{"missing.txt", "short.txt"} {
+		data, _ := os.ReadFile(filepath.Join(itemDir, name))
+		if string(data) != map[string]string{"missing.txt": "gone", "short.txt": "full"}[name] {
+			t.Errorf("%s = %q after repair", name, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "app_108600_workshop_2")); !os.IsNotExist(err) {
+		t.Error("repaired files of another item")
+	}
+}
+
+func TestRepairRejectsPathsOutsideOutput(t *testing.T) {
+	for _, path := range []string{"../escape.txt", "/etc/escape.txt", "app_108600_workshop_1/../../escape.txt"} {
+		manifestPath := filepath.Join(t.TempDir(), "files.txt")
+		os.WriteFile(manifestPath, []byte(path+"\t4\t-\n"), 0644)
+		if _, err := loadOutputManifest(manifestPath); err == nil {
+			t.Errorf("loadOutputManifest() accepted %q", path)
+		}
+	}
+
+	// Entries that didn't come from loadOutputManifest are checked as well.
+	// The path resolves to a file that exists next to the download, so only
+	// the check keeps it from being copied next to the output.
+	root := t.TempDir()
+	src, out := filepath.Join(root, "a", "b", "download"), filepath.Join(root, "a", "b", "out")
+	os.MkdirAll(src, 0755)
+	os.WriteFile(filepath.Join(root, "a", "escape.txt"), []byte("evil"), 0644)
+	path := "app_108600_workshop_1/../../escape.txt"
+	expected := map[string]outputFile{path: {Path: path, Size: 4}}
+	item := &steamcmd.WorkshopItem{PathToFile: src}
+	if err := repairItemOutput(io.Discard, item, out, "app_108600_workshop_1", expected, fsutil.Options{}); err == nil {
+		t.Error("repairItemOutput() accepted a path outside the output")
+	}
+	if _, err := os.Stat(filepath.Join(root, "a", "b", "escape.txt")); !os.IsNotExist(err) {
+		t.Error("repairItemOutput() wrote outside the output")
+	}
+}
@@ -2,19 +2,54 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/backoff"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 var (
 	cfgFile     string
+	profile     string
 	configDir   string
 	downloadDir string
 	steamcmdDir string
+	cacheDirArg string
 	verbose     bool
+	maxRetries  uint64
+	jsonLines   bool
+	outputFmt   string
+	noEmoji     bool
+	persistent  bool
+	lockWait    time.Duration
+	manifestOut string
+	failedOut   string
+
+	maxConsecutiveFailures int
+	maxFailuresPerMinute   int
+	breakerCooldown        time.Duration
+	loginTimeout           time.Duration
+	steamcmdTimeout        time.Duration
+	retryMode              string
+	platform               string
+	concurrency            string
+	ignoreCertErrors       bool
+	timings                bool
+	logFile                string
+	logLevel               string
+	logMaxSize             string
+	logMaxBackups          int
 )
 
 // Build information
@@ -37,6 +72,12 @@ Features:
 - Configurable download directories
 - Support for different Steam apps`,
 	Version: buildVersion,
+
+	// Execute's caller reports errors once, through ReportError, as text or
+	// as JSON; printing them here as well would duplicate them and, in JSON
+	// mode, put plain text on stderr before a flag is even parsed
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 // SetVersionInfo sets the version information for the CLI
@@ -50,33 +91,154 @@ func SetVersionInfo(version, commit, buildTimeStr string) {
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
-	return rootCmd.Execute()
+	start := time.Now()
+
+	// Stop the persistent session, then delete any password scripts, then
+	// release the lock, also on Ctrl+C
+	atExit(releaseSteamCMDLock)
+	atExit(steamcmd.RemoveRunscripts)
+	atExit(closeSteamCMDClient)
+	stop := exitOnSignal(start)
+	defer stop()
+
+	cmd, err := rootCmd.ExecuteC()
+	runExitFuncs()
+	logRunFinished(cmd, err, time.Since(start))
+	return err
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
-
+	// Loading the configuration before each command rather than in
+	// cobra.OnInitialize lets its errors reach Execute's caller, reported and
+	// cleaned up after like any other error
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return initConfig()
+	}
 	// Here you will define your flags and configuration settings.
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.workshop.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "use the settings of this profile from the config file's 'profiles' section")
 	rootCmd.PersistentFlags().StringVar(&downloadDir, "download-dir", "", "directory to download workshop items to")
 	rootCmd.PersistentFlags().StringVar(&steamcmdDir, "steamcmd-dir", "", "directory where SteamCMD is installed")
+	rootCmd.PersistentFlags().StringVar(&cacheDirArg, "cache-dir", "", "directory for the tool's own state, such as cached App IDs (default $HOME/.workshop/cache)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().Uint64Var(&maxRetries, "max-retries", backoff.DefaultMaxRetries, "number of retries for SteamCMD downloads and HTTP requests")
+	rootCmd.PersistentFlags().BoolVar(&jsonLines, "json-lines", false, "emit one JSON object per completed item to stdout, with progress text on stderr (download, import); same as --output-format json")
+	rootCmd.PersistentFlags().StringVar(&outputFmt, "output-format", "text", "text, or json for one JSON object per completed item on stdout and failures as a JSON error object on stderr")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "use plain ASCII markers like [OK] instead of emoji (also implied by NO_COLOR or non-TTY output)")
+	rootCmd.PersistentFlags().BoolVar(&persistent, "persistent", false, "experimental: reuse one SteamCMD process for all downloads instead of one per item")
+	rootCmd.PersistentFlags().DurationVar(&lockWait, "lock-wait", 0, "how long to wait for another workshop command using the same SteamCMD directory (0 fails immediately)")
+	rootCmd.PersistentFlags().StringVar(&manifestOut, "manifest-out", "", "write a lockfile recording the manifest ID, size and SHA-256 of each downloaded item (download, import)")
+	rootCmd.PersistentFlags().StringVar(&failedOut, "failed-out", "", "write the items a batch download failed or never reached to this file, for a later download --from-file (download, import)")
+	rootCmd.PersistentFlags().IntVar(&maxConsecutiveFailures, "max-consecutive-failures", 3, "abort a batch download after this many items in a row fail with network, timeout, rate-limit or server errors, as in a Steam outage (0 disables)")
+	rootCmd.PersistentFlags().StringVar(&retryMode, "retry-mode", steamcmd.RetryLenient, "which SteamCMD failures to retry: lenient (anything possibly transient) or strict (only clear network/server errors)")
+	rootCmd.PersistentFlags().StringVar(&platform, "platform", "", "download content for another OS than this one: windows, macos or linux (SteamCMD's @sSteamCmdForcePlatformType)")
+	rootCmd.PersistentFlags().StringVar(&concurrency, "concurrency", "1", "items a batch download works on at once; SteamCMD still downloads one item at a time, so only lookups and copies to --output overlap; a number, or auto for half the CPUs capped at 3")
+	rootCmd.PersistentFlags().DurationVar(&loginTimeout, "steamcmd-timeout-login", 3*time.Minute, "stop SteamCMD if it hasn't logged in after this long, e.g. when stuck waiting for a Steam Guard code (0 disables)")
+	rootCmd.PersistentFlags().DurationVar(&steamcmdTimeout, "steamcmd-timeout", 0, "stop a SteamCMD run, login and download included, after this long (0 disables)")
+	rootCmd.PersistentFlags().IntVar(&maxFailuresPerMinute, "max-failures-per-minute", 0, "pause all SteamCMD attempts once this many attempts fail within a minute, as Steam is likely degraded; successful attempts don't count (0 disables)")
+	rootCmd.PersistentFlags().DurationVar(&breakerCooldown, "breaker-cooldown", 2*time.Minute, "with --max-failures-per-minute, how long to pause new SteamCMD attempts once the failure limit is reached")
+	rootCmd.PersistentFlags().BoolVar(&timings, "timings", false, "print how long each phase of a download took (lookup, SteamCMD start, download, output), with totals for batches (download, import)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "append the tool's own activity log (runs, items, retries) to this file as JSON lines; separate from SteamCMD's console log")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "with --log-file, the least severe messages to log: debug (includes SteamCMD output), info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&logMaxSize, "log-max-size", "10MB", "with --log-file, rotate the log before it grows past this size (0 never rotates)")
+	rootCmd.PersistentFlags().IntVar(&logMaxBackups, "log-max-backups", 3, "with --log-file, how many rotated logs to keep as <file>.1 to <file>.N")
+	rootCmd.PersistentFlags().BoolVar(&ignoreCertErrors, "ignore-cert-errors", false, "DANGEROUS: skip TLS certificate verification for workshop pages and the SteamCMD installer; only for proxies that intercept TLS (alias --insecure)")
+
+	// The breaker's flag was first released as --max-attempts-per-minute
+	rootCmd.PersistentFlags().IntVar(&maxFailuresPerMinute, "max-attempts-per-minute", 0, "")
+	rootCmd.PersistentFlags().MarkDeprecated("max-attempts-per-minute", "use --max-failures-per-minute instead")
+
+	// --insecure is the name most tools use for the same thing
+	rootCmd.SetGlobalNormalizationFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "insecure" {
+			name = "ignore-cert-errors"
+		}
+		return pflag.NormalizedName(name)
+	})
 
 	// Bind flags to viper
-	viper.BindPFlag("download_dir", rootCmd.PersistentFlags().Lookup("download-dir"))
-	viper.BindPFlag("steamcmd_dir", rootCmd.PersistentFlags().Lookup("steamcmd-dir"))
-	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	bindFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	bindFlag("download_dir", rootCmd.PersistentFlags().Lookup("download-dir"))
+	bindFlag("steamcmd_dir", rootCmd.PersistentFlags().Lookup("steamcmd-dir"))
+	bindFlag("cache_dir", rootCmd.PersistentFlags().Lookup("cache-dir"))
+	bindFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	bindFlag("max_retries", rootCmd.PersistentFlags().Lookup("max-retries"))
+	bindFlag("json_lines", rootCmd.PersistentFlags().Lookup("json-lines"))
+	bindFlag("output_format", rootCmd.PersistentFlags().Lookup("output-format"))
+	bindFlag("no_emoji", rootCmd.PersistentFlags().Lookup("no-emoji"))
+	bindFlag("max_consecutive_failures", rootCmd.PersistentFlags().Lookup("max-consecutive-failures"))
+	bindFlag("retry_mode", rootCmd.PersistentFlags().Lookup("retry-mode"))
+	bindFlag("platform", rootCmd.PersistentFlags().Lookup("platform"))
+	bindFlag("concurrency", rootCmd.PersistentFlags().Lookup("concurrency"))
+	bindFlag("steamcmd_timeout_login", rootCmd.PersistentFlags().Lookup("steamcmd-timeout-login"))
+	bindFlag("steamcmd_timeout", rootCmd.PersistentFlags().Lookup("steamcmd-timeout"))
+	bindFlag("max_failures_per_minute", rootCmd.PersistentFlags().Lookup("max-failures-per-minute"))
+	bindFlag("breaker_cooldown", rootCmd.PersistentFlags().Lookup("breaker-cooldown"))
+	bindFlag("persistent", rootCmd.PersistentFlags().Lookup("persistent"))
+	bindFlag("lock_wait", rootCmd.PersistentFlags().Lookup("lock-wait"))
+	bindFlag("manifest_out", rootCmd.PersistentFlags().Lookup("manifest-out"))
+	bindFlag("failed_out", rootCmd.PersistentFlags().Lookup("failed-out"))
+	bindFlag("timings", rootCmd.PersistentFlags().Lookup("timings"))
+	bindFlag("ignore_cert_errors", rootCmd.PersistentFlags().Lookup("ignore-cert-errors"))
+	bindFlag("log_file", rootCmd.PersistentFlags().Lookup("log-file"))
+	bindFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level"))
+	bindFlag("log_max_size", rootCmd.PersistentFlags().Lookup("log-max-size"))
+	bindFlag("log_max_backups", rootCmd.PersistentFlags().Lookup("log-max-backups"))
+}
+
+// flagBindings records which flag feeds each viper key, for config debugging
+var flagBindings = map[string]*pflag.Flag{}
+
+// bindFlag binds a flag to a viper key and remembers the binding
+func bindFlag(key string, flag *pflag.Flag) {
+	viper.BindPFlag(key, flag)
+	flagBindings[key] = flag
+}
+
+// renamedKeys maps the old names of renamed settings to their current ones.
+// The old flag, environment variable and config key keep working.
+var renamedKeys = map[string]string{
+	"max_attempts_per_minute": "max_failures_per_minute",
+}
+
+// applyRenamedKeys feeds each renamed setting from its old name, in the
+// usual order of precedence: a flag, then the environment, then the config
+// file, where the current name wins over the old one at the same level
+func applyRenamedKeys() {
+	for old, key := range renamedKeys {
+		flag := rootCmd.PersistentFlags().Lookup(strings.ReplaceAll(old, "_", "-"))
+		if flag != nil && flag.Changed && !flagBindings[key].Changed {
+			bindFlag(key, flag)
+		}
+		viper.BindEnv(key, envName(key), envName(old))
+		if viper.InConfig(old) && !viper.InConfig(key) {
+			viper.RegisterAlias(old, key) // Moves the file's value to the current key
+		}
+	}
+}
+
+// envPrefix prefixes the environment variables read as configuration
+const envPrefix = "WORKSHOP"
+
+// envKeyReplacer maps config keys to environment variable names
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// envName returns the environment variable that sets a config key
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
 }
 
 // initConfig reads in config file and ENV variables if set.
-func initConfig() {
+func initConfig() error {
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
 		// Find home directory.
 		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
+		if err != nil {
+			return err
+		}
 
 		// Search config in home directory with name ".workshop" (without extension).
 		viper.AddConfigPath(home)
@@ -84,7 +246,12 @@ func initConfig() {
 		viper.SetConfigName(".workshop")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// Read in matching environment variables. The prefix keeps generic names
+	// like USERNAME or OUTPUT from leaking into the config: download_dir is
+	// read from WORKSHOP_DOWNLOAD_DIR.
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(envKeyReplacer)
+	viper.AutomaticEnv()
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
@@ -93,8 +260,254 @@ func initConfig() {
 		}
 	}
 
+	// Overlay the selected profile on the top-level settings
+	if name := viper.GetString("profile"); name != "" {
+		if err := applyProfile(name); err != nil {
+			return err
+		}
+	}
+
+	applyRenamedKeys()
+
 	// Set default values
 	setDefaults()
+
+	// Normalize configured directories once so every filepath.Join sees a clean absolute path
+	for _, key := range []string{"steamcmd_dir", "download_dir", "cache_dir"} {
+		dir, err := normalizeDir(viper.GetString(key))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		viper.Set(key, dir)
+	}
+
+	// Start the tool's own log before anything worth logging happens
+	if err := openLog(); err != nil {
+		return err
+	}
+
+	// Share the retry count with the scraper and installer HTTP calls
+	httpclient.MaxRetries = viper.GetUint64("max_retries")
+
+	// Accepting any certificate is a last resort, so never do it silently
+	if viper.GetBool("ignore_cert_errors") {
+		httpclient.InsecureSkipVerify = true
+		fmt.Fprintf(os.Stderr, "%s TLS certificate verification is disabled (ignore_cert_errors); anyone on the network path can tamper with downloads\n", iconWarn)
+	}
+
+	// Per-category retry schedules, e.g. backoff.rate_limit.base: 1m
+	if err := applyBackoffSchedules(); err != nil {
+		return err
+	}
+
+	if format := viper.GetString("output_format"); format != "text" && format != "json" {
+		return fmt.Errorf("invalid --output-format %q: must be text or json", format)
+	}
+	return nil
+}
+
+// cacheDir returns the directory the tool keeps its own state in, creating
+// it on first use. Everything cached between runs belongs under it.
+func cacheDir() (string, error) {
+	dir := viper.GetString("cache_dir")
+	if dir == "" {
+		return "", fmt.Errorf("no cache directory configured; set --cache-dir or cache_dir")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// applyBackoffSchedules overrides the retry schedules of the failure
+// categories with the backoff.<category>.base and .max settings
+func applyBackoffSchedules() error {
+	for _, category := range backoff.Categories {
+		schedule := backoff.Schedules[category]
+		for _, field := range []struct {
+			name string
+			dst  *time.Duration
+		}{{"base", &schedule.Base}, {"max", &schedule.Max}} {
+			key := fmt.Sprintf("backoff.%s.%s", category, field.name)
+			value := viper.GetString(key)
+			if value == "" {
+				continue
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 || (field.name == "base" && d == 0) {
+				return fmt.Errorf("invalid %s %q: must be a positive duration such as 5s", key, value)
+			}
+			*field.dst = d
+		}
+		backoff.Schedules[category] = schedule
+	}
+	return nil
+}
+
+// applyProfile merges the settings of profiles.<name> over the top-level
+// config file settings. Flags and environment variables still take precedence.
+func applyProfile(name string) error {
+	profiles := viper.GetStringMap("profiles")
+	settings, ok := profiles[name].(map[string]any)
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("profile %q not found: the config file defines no profiles", name)
+		}
+		return fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	return viper.MergeConfigMap(settings)
+}
+
+// normalizeDir expands a leading ~, makes the path absolute and cleans it.
+// It fails if the path exists but isn't a directory.
+func normalizeDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+
+	if dir == "~" || strings.HasPrefix(dir, "~/") || strings.HasPrefix(dir, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot expand ~: %w", err)
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("%s is a file, not a directory", abs)
+	}
+
+	return abs, nil
+}
+
+// sharedClient is the client every command of the run reuses with
+// --persistent, so its SteamCMD session outlives a single download.
+// closeSteamCMDClient stops that session when the run ends.
+var sharedClient *steamcmd.Client
+
+// newSteamCMDClient creates a SteamCMD client from the resolved configuration,
+// or returns the shared one with --persistent once it exists
+func newSteamCMDClient(w io.Writer) (*steamcmd.Client, error) {
+	if sharedClient != nil {
+		return sharedClient, nil
+	}
+
+	mode := viper.GetString("retry_mode")
+	if mode != steamcmd.RetryLenient && mode != steamcmd.RetryStrict {
+		return nil, fmt.Errorf("invalid --retry-mode %q: must be lenient or strict", mode)
+	}
+
+	if err := steamcmd.ValidatePlatform(viper.GetString("platform")); err != nil {
+		return nil, fmt.Errorf("--platform: %w", err)
+	}
+
+	client, err := steamcmd.NewClient(viper.GetString("steamcmd_dir"))
+	if err != nil {
+		return nil, err
+	}
+	client.RetryMode = mode
+	client.Platform = viper.GetString("platform")
+	client.MaxRetries = viper.GetUint64("max_retries")
+	client.LoginTimeout = viper.GetDuration("steamcmd_timeout_login")
+	client.Timeout = viper.GetDuration("steamcmd_timeout")
+	client.RetryOnEmpty = viper.GetBool("retry_on_empty")
+	client.Out = w
+	client.OnRetry = func(attempt int, max uint64, lastErr error) {
+		fmt.Fprintf(w, "Retry attempt %d/%d...\n", attempt, max)
+		logger.Warn("retrying SteamCMD", "attempt", attempt, "max", max, "error", fmt.Sprint(lastErr))
+		if viper.GetBool("verbose") && lastErr != nil {
+			fmt.Fprintf(w, "  previous attempt failed: %v\n", lastErr)
+		}
+	}
+	client.OnResume = func(attempt int, cached, previous steamcmd.Progress) {
+		fmt.Fprintln(w, resumeMessage(cached, previous))
+	}
+
+	client.Breaker = sharedBreaker()
+
+	if viper.GetBool("persistent") {
+		client.Persistent = true
+		sharedClient = client
+	}
+
+	// Stream SteamCMD output live in verbose mode
+	if viper.GetBool("verbose") {
+		client.OnOutput = func(line string) {
+			fmt.Fprintf(w, "  steamcmd> %s\n", line)
+		}
+	}
+	if logEnabled(slog.LevelDebug) {
+		onOutput := client.OnOutput
+		client.OnOutput = func(line string) {
+			logger.Debug("steamcmd output", "line", line)
+			if onOutput != nil {
+				onOutput(line)
+			}
+		}
+	}
+
+	return client, nil
+}
+
+// resumeMessage tells whether a retry is building on the previous attempt:
+// how much SteamCMD already had cached when the retry started reporting
+// progress, or that it started over
+func resumeMessage(cached, previous steamcmd.Progress) string {
+	if cached.Done > 0 {
+		msg := fmt.Sprintf("%s Resuming: %s of %s already cached (%.0f%%)",
+			iconOK, formatBytes(cached.Done), formatBytes(cached.Total), cached.Percent())
+		if previous.Done > cached.Done {
+			msg += fmt.Sprintf("; the previous attempt reached %.0f%%", previous.Percent())
+		}
+		return msg
+	}
+	if previous.Done > 0 {
+		return fmt.Sprintf("%s Starting over: the %s the previous attempt fetched wasn't kept", iconWarn, formatBytes(previous.Done))
+	}
+	return fmt.Sprintf("%s Starting from scratch: nothing of %s cached yet", iconWarn, formatBytes(cached.Total))
+}
+
+// breaker is shared by every SteamCMD client of the run. Parallel batch
+// workers create clients concurrently, so it's created once under breakerOnce.
+var (
+	breaker     *steamcmd.Breaker
+	breakerOnce sync.Once
+)
+
+// sharedBreaker returns the run's circuit breaker, or nil when
+// --max-failures-per-minute is off
+func sharedBreaker() *steamcmd.Breaker {
+	breakerOnce.Do(func() {
+		threshold := viper.GetInt("max_failures_per_minute")
+		if threshold <= 0 {
+			return
+		}
+
+		breaker = steamcmd.NewBreaker(threshold, time.Minute, viper.GetDuration("breaker_cooldown"))
+		breaker.OnOpen = func(failures int, pause time.Duration) {
+			fmt.Fprintf(humanOutput(), "%s %d SteamCMD attempts failed within a minute (--max-failures-per-minute); Steam may be degraded. Pausing new attempts for %s...\n",
+				iconWarn, failures, pause)
+		}
+	})
+	return breaker
+}
+
+// closeSteamCMDClient stops the persistent SteamCMD session, if any
+func closeSteamCMDClient() {
+	if sharedClient != nil {
+		sharedClient.Close()
+	}
 }
 
 func setDefaults() {
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/spf13/viper"
+)
+
+// fakeSessionSteamCMD writes a SteamCMD stand-in that stays at its prompt,
+// downloading items until it's told to quit. It records each start in
+// starts.log and each quit in quits.log.
+func fakeSessionSteamCMD(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as SteamCMD")
+	}
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+echo start >> starts.log
+echo "Loading Steam API...OK"
+echo "Connecting anonymously to Steam Public...OK"
+while read cmd app id; do
+  case $cmd in
+    workshop_download_item)
+      mkdir -p "content/$app/$id" && echo hi > "content/$app/$id/a.txt"
+      echo "Success. Downloaded item $id to \"$PWD/content/$app/$id\" (3 bytes)";;
+    quit) echo quit >> quits.log; exit 0;;
+  esac
+done
+`
+	if err := os.WriteFile(filepath.Join(dir, "steamcmd.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// countLines returns how many lines the file has, 0 when it doesn't exist
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+// useSteamCMDDir points the configuration at dir for the test, leaving no
+// shared client behind
+func useSteamCMDDir(t *testing.T, dir string, persistent bool) {
+	t.Helper()
+	viper.Set("steamcmd_dir", dir)
+	viper.Set("retry_mode", steamcmd.RetryLenient)
+	viper.Set("persistent", persistent)
+	t.Cleanup(func() {
+		closeSteamCMDClient()
+		sharedClient = nil
+		for _, key := range []string{"steamcmd_dir", "retry_mode", "persistent"} {
+			viper.Set(key, nil)
+		}
+	})
+}
+
+func TestNewSteamCMDClientPersistentSession(t *testing.T) {
+	dir := fakeSessionSteamCMD(t)
+	useSteamCMDDir(t, dir, true)
+
+	for _, id := range []string{"1", "2"} {
+		client, err := newSteamCMDClient(io.Discard)
+		if err != nil {
+			t.Fatalf("newSteamCMDClient() unexpected error = %v", err)
+		}
+		if client != sharedClient {
+			t.Fatal("newSteamCMDClient() with --persistent should return the shared client")
+		}
+
+		item, err := client.DownloadWorkshopItem("4000", id, "")
+		if err != nil || !item.Success {
+			t.Fatalf("DownloadWorkshopItem(%s) = %+v, %v; want success", id, item, err)
+		}
+	}
+
+	if n := countLines(t, filepath.Join(dir, "starts.log")); n != 1 {
+		t.Errorf("SteamCMD started %d times for two items, want one session", n)
+	}
+
+	// Closing at the end of the run asks the session to quit
+	closeSteamCMDClient()
+	if n := countLines(t, filepath.Join(dir, "quits.log")); n != 1 {
+		t.Errorf("session quit %d times after closeSteamCMDClient(), want 1", n)
+	}
+
+	// A second close has no session left to stop
+	closeSteamCMDClient()
+	if n := countLines(t, filepath.Join(dir, "quits.log")); n != 1 {
+		t.Errorf("session quit %d times after a second close, want 1", n)
+	}
+}
+
+func TestNewSteamCMDClientWithoutPersistent(t *testing.T) {
+	dir := fakeSessionSteamCMD(t)
+	useSteamCMDDir(t, dir, false)
+
+	first, err := newSteamCMDClient(io.Discard)
+	if err != nil {
+		t.Fatalf("newSteamCMDClient() unexpected error = %v", err)
+	}
+	second, err := newSteamCMDClient(io.Discard)
+	if err != nil {
+		t.Fatalf("newSteamCMDClient() unexpected error = %v", err)
+	}
+	if first == second || sharedClient != nil {
+		t.Error("newSteamCMDClient() without --persistent should create a new client each time")
+	}
+
+	// Nothing to stop without a session
+	closeSteamCMDClient()
+}
+
+func TestRenamedKeyFromEnvironment(t *testing.T) {
+	t.Setenv("WORKSHOP_MAX_ATTEMPTS_PER_MINUTE", "7")
+	applyRenamedKeys()
+
+	if got := viper.GetInt("max_failures_per_minute"); got != 7 {
+		t.Errorf("max_failures_per_minute = %d, want 7 from the old variable", got)
+	}
+	if got := configSource("max_failures_per_minute"); got != "env WORKSHOP_MAX_ATTEMPTS_PER_MINUTE" {
+		t.Errorf("configSource() = %q, want the old variable", got)
+	}
+
+	t.Setenv("WORKSHOP_MAX_FAILURES_PER_MINUTE", "3")
+	if got := viper.GetInt("max_failures_per_minute"); got != 3 {
+		t.Errorf("max_failures_per_minute = %d, want 3 from the current variable", got)
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+)
+
+// simulatedFailures are the failures --simulate can produce, as SteamCMD would report them
+var simulatedFailures = map[string]*steamcmd.DownloadError{
+	"network-error":     {Kind: steamcmd.ErrTimeout, Err: errors.New("download failed: Download failed: Timeout")},
+	"auth-required":     {Kind: steamcmd.ErrNotLoggedOn, Err: errors.New("not logged on to Steam. Please run 'workshop login' first to authenticate")},
+	"login-failed":      {Kind: steamcmd.ErrLoginFailed, Err: errors.New("download failed: Login failed: Invalid Password")},
+	"access-denied":     {Kind: steamcmd.ErrAccessDenied, Err: errors.New("download failed: Download failed: Access Denied")},
+	"not-owned":         {Kind: steamcmd.ErrNotOwned, Err: errors.New("download failed: Download failed: No subscription")},
+	"not-found":         {Kind: steamcmd.ErrItemNotFound, Err: errors.New("download failed: Download failed: File Not Found")},
+	"unexpected-output": {Kind: steamcmd.ErrUnexpectedOutput, Err: errors.New("failed to parse SteamCMD output: unhandled SteamCMD output: ???")},
+}
+
+// simulationModes lists the accepted --simulate values
+func simulationModes() string {
+	modes := []string{"success"}
+	for mode := range simulatedFailures {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes[1:])
+	return strings.Join(modes, ", ")
+}
+
+// simulateDownload fakes the outcome of a download without running SteamCMD,
+// so wrappers can exercise their handling of each result in CI
+func simulateDownload(w io.Writer, result *downloadResult, mode string) error {
+	fmt.Fprintf(w, "Simulating download of workshop item %s for app %s (%s)...\n", result.WorkshopID, result.AppID, mode)
+
+	if mode == "success" {
+		result.Status = statusDownloaded
+		fmt.Fprintln(w, "Simulated download succeeded")
+		return nil
+	}
+
+	failure, ok := simulatedFailures[mode]
+	if !ok {
+		return fmt.Errorf("unknown --simulate mode %q (valid: %s)", mode, simulationModes())
+	}
+	return fmt.Errorf("download failed: %w", failure)
+}
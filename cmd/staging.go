@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// versionLayout names the versioned directories created by --latest-link
+const versionLayout = "20060102T150405Z"
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote [run-dir]",
+	Short: "Move staged workshop items to their final output directory",
+	Long: `Move every workshop item staged by 'workshop download --staging-dir' into the
+output directory, replacing any previous copy. The run directory is removed once
+all of its items have been promoted.
+
+Example:
+  workshop download 108600 2503622437 --output ./mods --staging-dir ./staging
+  workshop promote ./staging/run-20250101T120000Z --output ./mods`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return promoteRun(args[0], viper.GetString("promote_output"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+
+	promoteCmd.Flags().StringP("output", "o", "", "Final output directory (required)")
+	promoteCmd.MarkFlagRequired("output")
+	bindFlag("promote_output", promoteCmd.Flags().Lookup("output"))
+}
+
+var (
+	stagingRunOnce sync.Once
+	stagingRun     string
+)
+
+// stagingRunDir returns the directory staging this run's items. All items of
+// a batch share it, so a whole run can be inspected and promoted at once.
+func stagingRunDir() string {
+	stagingRunOnce.Do(func() {
+		stagingRun = filepath.Join(viper.GetString("staging_dir"), "run-"+time.Now().UTC().Format(versionLayout))
+	})
+	return stagingRun
+}
+
+// promoteRun moves every staged item in runDir into outputDir
+func promoteRun(runDir, outputDir string) error {
+	opts, err := copyOptionsFromConfig(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(runDir)
+	if err != nil {
+		return fmt.Errorf("failed to read staging run: %w", err)
+	}
+
+	promoted := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "app_") {
+			continue
+		}
+
+		staged := filepath.Join(runDir, entry.Name())
+		final := filepath.Join(outputDir, entry.Name())
+		if err := promoteStagedItem(staged, final, opts); err != nil {
+			return fmt.Errorf("failed to promote %s: %w", entry.Name(), err)
+		}
+		fmt.Printf("%s Promoted %s to %s\n", iconOK, entry.Name(), final)
+		promoted++
+	}
+
+	if promoted == 0 {
+		return fmt.Errorf("no staged items found in %s", runDir)
+	}
+
+	// Only remove the run once it's empty, so nothing unexpected is lost
+	if err := os.Remove(runDir); err != nil {
+		fmt.Printf("%s Could not remove staging run %s: %v\n", iconWarn, runDir, err)
+	}
+
+	return nil
+}
+
+// promoteStagedItem promotes one staged item directory. Items staged with
+// --latest-link hold version subdirectories; those are moved next to the
+// existing versions and the latest link is pointed at the newest one.
+func promoteStagedItem(staged, final string, opts fsutil.Options) error {
+	versions, err := stagedVersions(staged)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		return promoteDir(staged, final, opts)
+	}
+
+	for _, version := range versions {
+		if err := promoteDir(filepath.Join(staged, version), filepath.Join(final, version), opts); err != nil {
+			return err
+		}
+	}
+	if _, err := updateLatestLink(final, versions[len(versions)-1]); err != nil {
+		return fmt.Errorf("failed to update latest link: %w", err)
+	}
+
+	return os.Remove(staged)
+}
+
+// stagedVersions returns the sorted version subdirectories of a staged item,
+// or nil when the item isn't versioned
+func stagedVersions(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if _, err := time.Parse(versionLayout, entry.Name()); err != nil || !entry.IsDir() {
+			return nil, nil
+		}
+		versions = append(versions, entry.Name())
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// promoteDir moves src to dst, replacing dst. The previous copy is kept aside
+// until the move succeeds and restored if it fails.
+func promoteDir(src, dst string, opts fsutil.Options) error {
+	if err := opts.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	previous := dst + ".previous"
+	os.RemoveAll(previous)
+	hadPrevious := false
+	if err := os.Rename(dst, previous); err == nil {
+		hadPrevious = true
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := fsutil.MoveDir(src, dst, opts); err != nil {
+		if hadPrevious {
+			os.RemoveAll(dst)
+			os.Rename(previous, dst)
+		}
+		return err
+	}
+
+	if hadPrevious {
+		os.RemoveAll(previous)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/steamcmd"
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/webapi"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status <workshop URL or ID>",
+	Short: "Show everything known about a workshop item",
+	Long: `Show whether a workshop item is downloaded, where and how large it is on
+disk, the version SteamCMD installed, its title and game, and whether Steam
+has a newer version. Nothing is downloaded or changed.
+
+The item is looked up among the downloaded items; pass --app-id when the
+same workshop ID was downloaded for several games. Online details come from
+the Steam Web API and are left out when it can't be reached.
+
+Examples:
+  workshop status 2503622437
+  workshop status 2503622437 --app-id 108600 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showItemStatus(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().String("app-id", "", "Steam App ID of the item (default: found among downloaded items, then online)")
+	statusCmd.Flags().Bool("json", false, "Print the report as JSON")
+	bindFlag("status_app_id", statusCmd.Flags().Lookup("app-id"))
+	bindFlag("status_json", statusCmd.Flags().Lookup("json"))
+}
+
+// itemStatus is the report of the status command
+type itemStatus struct {
+	AppID      string `json:"app_id"`
+	WorkshopID string `json:"workshop_id"`
+	Title      string `json:"title"`
+	Game       string `json:"game"`
+
+	Downloaded  bool      `json:"downloaded"`
+	Path        string    `json:"path"`
+	SizeBytes   int64     `json:"size_bytes"`
+	ManifestID  string    `json:"manifest_id"`
+	InstalledAt time.Time `json:"installed_version"` // Publish time of the installed version
+
+	PublishedSizeBytes int64     `json:"published_size_bytes"`
+	LastUpdated        time.Time `json:"last_updated"`
+	Update             string    `json:"update"`
+}
+
+func showItemStatus(arg string) error {
+	workshopID, err := workshopIDArg(arg)
+	if err != nil {
+		return err
+	}
+
+	jsonOut := viper.GetBool("status_json")
+	warn := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "%s "+format+"\n", append([]any{iconWarn}, args...)...)
+	}
+
+	client, err := newSteamCMDClient(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to create SteamCMD client: %w", err)
+	}
+
+	status := itemStatus{WorkshopID: workshopID, AppID: viper.GetString("status_app_id"), Update: updateUnknown}
+
+	// Online details first: they name the game when the item isn't downloaded
+	details, err := webapi.GetPublishedFileDetails(workshopID)
+	if err != nil {
+		warn("Could not look up the item online: %v", err)
+		details = nil
+	} else {
+		status.Title = details.Title
+		status.PublishedSizeBytes = details.SizeBytes
+		status.LastUpdated = details.TimeUpdated
+	}
+
+	if status.AppID == "" {
+		apps, err := downloadedApps(client, workshopID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(apps) == 1:
+			status.AppID = apps[0]
+		case len(apps) > 1:
+			return fmt.Errorf("workshop item %s is downloaded for several apps (%v); pass --app-id", workshopID, apps)
+		case details != nil:
+			status.AppID = details.AppID
+		}
+	} else if err := ValidateAppID(status.AppID); err != nil {
+		return err
+	}
+
+	if status.AppID != "" {
+		if name, err := webapi.GetAppName(status.AppID); err == nil {
+			status.Game = name
+		} else if app, ok := lookupKnownApp(status.AppID); ok {
+			status.Game = app.Name
+		}
+
+		exists, path, err := client.CheckWorkshopItemExists(status.AppID, workshopID)
+		if err != nil {
+			return fmt.Errorf("failed to check workshop item: %w", err)
+		}
+		if exists {
+			status.Downloaded = true
+			status.Path = path
+			status.SizeBytes = getDirSize(path)
+
+			if installed, err := client.GetInstalledItem(status.AppID, workshopID); err == nil {
+				status.ManifestID = installed.ManifestID
+				if seconds, err := strconv.ParseInt(installed.TimeUpdated, 10, 64); err == nil && seconds > 0 {
+					status.InstalledAt = time.Unix(seconds, 0).UTC()
+				}
+			}
+		}
+	}
+
+	status.Update = updateStatus(status)
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
+	printItemStatus(status)
+	return nil
+}
+
+// updateStatus compares the installed version with the published one, by
+// publish time when both are known and by size otherwise
+func updateStatus(s itemStatus) string {
+	switch {
+	case !s.Downloaded:
+		return updateNotDownloaded
+	case !s.InstalledAt.IsZero() && !s.LastUpdated.IsZero():
+		if s.LastUpdated.After(s.InstalledAt) {
+			return updateAvailable
+		}
+		return updateUpToDate
+	case s.PublishedSizeBytes > 0:
+		if s.PublishedSizeBytes != s.SizeBytes {
+			return updateAvailable
+		}
+		return updateUpToDate
+	}
+	return updateUnknown
+}
+
+func printItemStatus(s itemStatus) {
+	orUnknown := func(v string) string {
+		if v == "" {
+			return "unknown"
+		}
+		return v
+	}
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "unknown"
+		}
+		return t.Local().Format(time.DateTime)
+	}
+
+	fmt.Printf("Workshop item %s\n", s.WorkshopID)
+	fmt.Printf("  Title:         %s\n", orUnknown(s.Title))
+	fmt.Printf("  Game:          %s (%s)\n", orUnknown(s.Game), orUnknown(s.AppID))
+	if s.Downloaded {
+		fmt.Printf("  Downloaded:    %s %s\n", iconOK, s.Path)
+		fmt.Printf("  Size on disk:  %s\n", formatBytes(s.SizeBytes))
+		fmt.Printf("  Installed:     version of %s, manifest %s\n", formatTime(s.InstalledAt), orUnknown(s.ManifestID))
+	} else {
+		fmt.Printf("  Downloaded:    no\n")
+	}
+	if s.PublishedSizeBytes > 0 {
+		fmt.Printf("  Published:     %s, last updated %s\n", formatBytes(s.PublishedSizeBytes), formatTime(s.LastUpdated))
+	}
+	fmt.Printf("  Update:        %s\n", s.Update)
+}
+
+// downloadedApps returns the apps a workshop item is downloaded for
+func downloadedApps(client *steamcmd.Client, workshopID string) ([]string, error) {
+	items, err := downloadedItems(client, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []string
+	for _, item := range items {
+		if item.WorkshopID == workshopID {
+			apps = append(apps, item.AppID)
+		}
+	}
+	return apps, nil
+}
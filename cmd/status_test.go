@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	installed := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status itemStatus
+		want   string
+	}{
+		{"not downloaded", itemStatus{LastUpdated: installed}, updateNotDownloaded},
+		{"newer version", itemStatus{Downloaded: true, InstalledAt: installed, LastUpdated: installed.Add(time.Hour)}, updateAvailable},
+		{"same version", itemStatus{Downloaded: true, InstalledAt: installed, LastUpdated: installed, SizeBytes: 1, PublishedSizeBytes: 2}, updateUpToDate},
+		{"size differs", itemStatus{Downloaded: true, SizeBytes: 1, PublishedSizeBytes: 2}, updateAvailable},
+		{"size matches", itemStatus{Downloaded: true, SizeBytes: 2, PublishedSizeBytes: 2}, updateUpToDate},
+		{"offline", itemStatus{Downloaded: true, InstalledAt: installed, SizeBytes: 2}, updateUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := updateStatus(tt.status); got != tt.want {
+			t.Errorf("%s: updateStatus() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
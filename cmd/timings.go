@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Phases of a download measured for --timings, in the order they happen
+const (
+	phaseLookup       = "lookup"         // Resolving the input: scraping or Web API lookups
+	phaseSteamCMDWait = "steamcmd_start" // Launching SteamCMD until its first output line
+	phaseDownload     = "download"       // The whole SteamCMD download, retries included
+	phaseOutput       = "output"         // Copying and extracting to the outputs and the game
+)
+
+var phaseOrder = []string{phaseLookup, phaseSteamCMDWait, phaseDownload, phaseOutput}
+
+// phaseTiming is how long one phase of a download took
+type phaseTiming struct {
+	Phase   string  `json:"phase"`
+	Seconds float64 `json:"seconds"`
+}
+
+// timePhase records the time since start as the elapsed time of phase
+func (r *downloadResult) timePhase(phase string, start time.Time) {
+	r.Timings = append(r.Timings, phaseTiming{Phase: phase, Seconds: time.Since(start).Seconds()})
+}
+
+// printTimings prints the phase breakdown of one item for --timings
+func printTimings(w io.Writer, timings []phaseTiming, total time.Duration) {
+	parts := make([]string, 0, len(timings))
+	for _, t := range timings {
+		parts = append(parts, fmt.Sprintf("%s %s", t.Phase, formatSeconds(t.Seconds)))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "no phases reached")
+	}
+	fmt.Fprintf(w, "Timings: %s (total %s)\n", strings.Join(parts, ", "), formatSeconds(total.Seconds()))
+}
+
+// printPhaseTotals prints the time a batch spent in each phase
+func printPhaseTotals(w io.Writer, totals map[string]float64) {
+	var parts []string
+	for _, phase := range phaseOrder {
+		if seconds, ok := totals[phase]; ok {
+			parts = append(parts, fmt.Sprintf("%s %s", phase, formatSeconds(seconds)))
+		}
+	}
+	if len(parts) > 0 {
+		fmt.Fprintf(w, "Time by phase: %s\n", strings.Join(parts, ", "))
+	}
+}
+
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.2fs", seconds)
+}
+
+// timingsEnabled reports whether --timings is on
+func timingsEnabled() bool {
+	return viper.GetBool("timings")
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/fsutil"
+	"github.com/spf13/viper"
+)
+
+// defaultTrimPatterns are removed by --trim-output: version control folders,
+// source assets, previews and editor junk that a server never loads. A
+// trailing slash matches directories only.
+var defaultTrimPatterns = []string{
+	".git/",
+	".svn/",
+	"source/",
+	"*.psd",
+	"*.xcf",
+	"*.blend1",
+	"preview.*",
+	"thumbs.db",
+	".ds_store",
+}
+
+// trimPatterns returns the built-in patterns plus the configured trim_patterns
+func trimPatterns() []string {
+	return append(append([]string(nil), defaultTrimPatterns...), viper.GetStringSlice("trim_patterns")...)
+}
+
+// trimMatches reports whether an entry at rel matches one of patterns.
+// Matching ignores case, since workshop authors are inconsistent about it.
+func trimMatches(patterns []string, rel string, isDir bool) bool {
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		if fsutil.MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimOutput removes the entries under dir matching the trim patterns and
+// returns how many were removed and the bytes freed
+func trimOutput(dir string) (int, int64, error) {
+	patterns := trimPatterns()
+	var removed int
+	var freed int64
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !trimMatches(patterns, rel, d.IsDir()) {
+			return nil
+		}
+
+		size := getDirSize(path)
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		removed++
+		freed += size
+
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	return removed, freed, err
+}
+
+// applyTrim runs --trim-output on an extracted item and reports what it freed
+func applyTrim(w io.Writer, dir string) error {
+	removed, freed, err := trimOutput(dir)
+	if err != nil {
+		return fmt.Errorf("failed to trim output: %w", err)
+	}
+	if removed > 0 {
+		fmt.Fprintf(w, "Trimmed %d non-essential file(s) or folder(s), freeing %s\n", removed, formatBytes(freed))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import "testing"
+
+func TestTrimMatches(t *testing.T) {
+	patterns := append(append([]string(nil), defaultTrimPatterns...), "docs/")
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{".git", true, true},
+		{"mods/sub/.git", true, true},
+		{"Source", true, true},
+		{"source", false, false}, // A file named like a trimmed folder stays
+		{"art/Logo.PSD", false, true},
+		{"preview.png", false, true},
+		{"media/preview.jpg", false, true},
+		{"Thumbs.db", false, true},
+		{"docs", true, true},
+		{"docs", false, false},
+		{"mod.info", false, false},
+		{"media/lua/client/main.lua", false, false},
+		{"previews", true, false},
+	}
+
+	for _, tt := range tests {
+		if got := trimMatches(patterns, tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("trimMatches(%q, isDir=%v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
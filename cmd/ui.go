@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// icon is a status marker shown as emoji on capable terminals and as plain
+// ASCII when emoji are disabled or stdout isn't a terminal
+type icon int
+
+const (
+	iconOK icon = iota
+	iconWarn
+	iconErr
+	iconTip
+	iconDir
+	iconStart
+)
+
+// icons holds the emoji and plain ASCII forms of each marker
+var icons = map[icon][2]string{
+	iconOK:    {"✅", "[OK]"},
+	iconWarn:  {"⚠️ ", "[WARN]"},
+	iconErr:   {"❌", "[ERR]"},
+	iconTip:   {"💡", "[TIP]"},
+	iconDir:   {"📁", "[DIR]"},
+	iconStart: {"🚀", "[..]"},
+}
+
+func (i icon) String() string {
+	if plainOutput() {
+		return icons[i][1]
+	}
+	return icons[i][0]
+}
+
+// plainOutput reports whether output should avoid emoji and color: when
+// --no-emoji is set, NO_COLOR is set (https://no-color.org), or stdout
+// isn't a terminal (e.g. piped into a log aggregator)
+func plainOutput() bool {
+	if viper.GetBool("no_emoji") {
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	return !isTerminal(os.Stdout)
+}
+
+// jsonOutput reports whether results and errors are written as JSON, with
+// --output-format json or its older spelling --json-lines
+func jsonOutput() bool {
+	return viper.GetBool("json_lines") || viper.GetString("output_format") == "json"
+}
+
+// humanOutput returns where human-readable progress goes: stdout, or stderr
+// in JSON mode, which keeps stdout for the JSON results
+func humanOutput() io.Writer {
+	if jsonOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// isTerminal reports whether f is attached to a terminal. Being a character
+// device isn't enough: /dev/null and NUL are ones too.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
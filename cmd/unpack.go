@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// unpackFormat returns the archive format --auto-unpack handles a file as,
+// or "" when it isn't one
+func unpackFormat(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".7z"):
+		return "7z"
+	case strings.HasSuffix(lower, ".rar"):
+		return "rar"
+	}
+	return ""
+}
+
+// unpackArchives extracts the archives found under dir next to themselves and
+// removes them unless --keep-archives is set. Archives that come out of
+// another archive are left packed.
+func unpackArchives(w io.Writer, dir string) error {
+	var archives []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && unpackFormat(d.Name()) != "" {
+			archives = append(archives, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan for archives: %w", err)
+	}
+
+	keep := viper.GetBool("keep_archives")
+	for _, path := range archives {
+		if err := unpackArchive(path, filepath.Dir(path)); err != nil {
+			return fmt.Errorf("failed to unpack %s: %w", filepath.Base(path), err)
+		}
+		fmt.Fprintf(w, "Unpacked %s\n", filepath.Base(path))
+
+		if !keep {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// unpackArchive extracts one archive into dest. Zip and tar are handled
+// natively; 7z and rar need an external tool on PATH.
+func unpackArchive(path, dest string) error {
+	switch unpackFormat(path) {
+	case "zip":
+		return extractZip(path, dest)
+	case "tar.gz":
+		return extractTarGz(path, dest)
+	case "tar":
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return extractTar(f, dest)
+	case "7z":
+		return runUnpacker(path, dest, "7z", "7za")
+	case "rar":
+		return runUnpacker(path, dest, "unrar", "7z", "7za")
+	}
+	return fmt.Errorf("unsupported archive format")
+}
+
+// runUnpacker extracts an archive with the first of tools found on PATH
+func runUnpacker(path, dest string, tools ...string) error {
+	for _, tool := range tools {
+		bin, err := exec.LookPath(tool)
+		if err != nil {
+			continue
+		}
+
+		var args []string
+		if tool == "unrar" {
+			args = []string{"x", "-o+", "-idq", path, dest + string(os.PathSeparator)}
+		} else {
+			args = []string{"x", "-y", "-bd", "-o" + dest, path}
+		}
+
+		out, err := exec.Command(bin, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s failed: %w\nOutput: %s", tool, err, out)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no extractor found; install one of %s", strings.Join(tools, ", "))
+}
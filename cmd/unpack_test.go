@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestUnpackFormat(t *testing.T) {
+	tests := map[string]string{
+		"mod.zip":        "zip",
+		"Mod.ZIP":        "zip",
+		"maps.tar.gz":    "tar.gz",
+		"maps.tgz":       "tar.gz",
+		"maps.tar":       "tar",
+		"textures.7z":    "7z",
+		"textures.rar":   "rar",
+		"pak01_dir.vpk":  "",
+		"readme.txt":     "",
+		"zip":            "",
+		"archive.tar.xz": "",
+	}
+
+	for name, want := range tests {
+		if got := unpackFormat(name); got != want {
+			t.Errorf("unpackFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestUnpackArchives(t *testing.T) {
+	dir := t.TempDir()
+
+	// A zip at the top and a tar.gz in a subdirectory
+	zf, err := os.Create(filepath.Join(dir, "mod.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	w, _ := zw.Create("mod/info.txt")
+	w.Write([]byte("zip"))
+	zw.Close()
+	zf.Close()
+
+	os.Mkdir(filepath.Join(dir, "maps"), 0755)
+	tf, err := os.Create(filepath.Join(dir, "maps", "maps.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(tf)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "map1.bsp", Mode: 0644, Size: 3, Typeflag: tar.TypeReg})
+	tw.Write([]byte("tar"))
+	tw.Close()
+	gw.Close()
+	tf.Close()
+
+	viper.Set("keep_archives", false)
+	defer viper.Set("keep_archives", nil)
+
+	if err := unpackArchives(io.Discard, dir); err != nil {
+		t.Fatalf("unpackArchives() unexpected error = %v", err)
+	}
+
+	for path, want := range map[string]string{
+		filepath.Join(dir, "mod", "info.txt"):  "zip",
+		filepath.Join(dir, "maps", "map1.bsp"): "tar",
+	} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", path, data, err, want)
+		}
+	}
+	for _, path := range []string{filepath.Join(dir, "mod.zip"), filepath.Join(dir, "maps", "maps.tar.gz")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed after unpacking", path)
+		}
+	}
+}
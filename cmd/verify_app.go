@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/webapi"
+	"github.com/spf13/viper"
+)
+
+// appsVerified remembers the apps --verify-app-id looked up this run, so a
+// batch asks the store once per app
+var appsVerified sync.Map
+
+// verifyAppID warns, with --verify-app-id, when the Steam Store doesn't know
+// the app, which is most likely a typo'd --app-id that would only fail after
+// a full download attempt. Without network nothing beyond ValidateAppID's
+// syntax check happens.
+func verifyAppID(w io.Writer, appID string) {
+	if !viper.GetBool("verify_app_id") {
+		return
+	}
+	if _, checked := appsVerified.LoadOrStore(appID, true); checked {
+		return
+	}
+
+	name, err := webapi.VerifyApp(appID)
+	switch {
+	case errors.Is(err, webapi.ErrAppNotFound):
+		fmt.Fprintf(w, "%s App %s isn't on the Steam Store; check the App ID for typos. Dedicated servers and tools may not be listed, so downloading anyway.\n", iconWarn, appID)
+	case err != nil:
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(w, "Could not verify app %s: %v\n", appID, err)
+		}
+	default:
+		fmt.Fprintf(w, "App %s: %s\n", appID, name)
+	}
+}
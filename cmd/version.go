@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show version information",
+	Long: `Show the tool's build information.
+
+Use --full to also run the installed SteamCMD and report its build,
+warning when it is old enough to cause workshop download problems.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showVersion()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().Bool("full", false, "Also report the installed SteamCMD version")
+	bindFlag("version_full", versionCmd.Flags().Lookup("full"))
+}
+
+func showVersion() error {
+	fmt.Printf("workshop %s\n", buildVersion)
+	fmt.Printf("  commit: %s\n", buildCommit)
+	fmt.Printf("  built:  %s\n", buildTime)
+
+	if !viper.GetBool("version_full") {
+		return nil
+	}
+
+	fmt.Println()
+	client, err := newSteamCMDClient(os.Stdout)
+	if err != nil {
+		fmt.Printf("SteamCMD: not available (%v)\n", err)
+		return nil
+	}
+
+	fmt.Printf("SteamCMD: %s\n", client.SteamCMDPath)
+	if err := lockSteamCMD(); err != nil {
+		return err
+	}
+	info, err := client.SteamCMDVersion()
+	if err != nil {
+		return fmt.Errorf("failed to determine SteamCMD version: %w", err)
+	}
+
+	fmt.Printf("  version: %s\n", info.Version)
+	if !info.BuiltAt.IsZero() {
+		fmt.Printf("  built:   %s\n", info.BuiltAt.Format("2006-01-02"))
+	}
+
+	if info.Outdated() {
+		fmt.Println()
+		fmt.Printf("%s This SteamCMD build is more than a year old and may have known workshop bugs.\n", iconWarn)
+		fmt.Printf("%s Reinstall it with: workshop install --force\n", iconTip)
+	}
+
+	return nil
+}
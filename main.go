@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/davidroman0O/steam-workshop-downloader/cmd"
@@ -20,7 +19,7 @@ func main() {
 
 	// Execute the CLI
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		cmd.ReportError(os.Stderr, err)
 		os.Exit(1)
 	}
 }
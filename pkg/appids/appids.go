@@ -0,0 +1,92 @@
+package appids
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileName is the name of the store file inside the cache directory
+const FileName = "appids.json"
+
+// Entry is what is cached about a workshop item
+type Entry struct {
+	AppID string `json:"app_id"`
+	Title string `json:"title,omitempty"` // Empty when the lookup failed or the App ID came from the user
+	Game  string `json:"game,omitempty"`
+
+	// TitleFetched is set once the title and game were looked up, even
+	// without finding them, so the lookup isn't repeated on every cache hit
+	TitleFetched bool `json:"title_fetched,omitempty"`
+}
+
+// UnmarshalJSON also accepts the bare App ID string earlier versions cached
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var appID string
+	if err := json.Unmarshal(data, &appID); err == nil {
+		*e = Entry{AppID: appID}
+		return nil
+	}
+
+	type entry Entry // Without the method, to avoid recursing
+	return json.Unmarshal(data, (*entry)(e))
+}
+
+// Store is a persistent workshop ID -> App ID map kept in the cache directory,
+// remembering the item's title and game along with it
+type Store struct {
+	path    string
+	entries map[string]Entry
+}
+
+// Open loads the store from cacheDir. A missing file yields an empty store.
+func Open(cacheDir string) (*Store, error) {
+	s := &Store{
+		path:    filepath.Join(cacheDir, FileName),
+		entries: make(map[string]Entry),
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read App ID cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse App ID cache %s: %w", s.path, err)
+	}
+
+	return s, nil
+}
+
+// Get returns the entry recorded for a workshop ID
+func (s *Store) Get(workshopID string) (Entry, bool) {
+	entry, ok := s.entries[workshopID]
+	return entry, ok
+}
+
+// Set records the entry of a workshop ID
+func (s *Store) Set(workshopID string, entry Entry) {
+	s.entries[workshopID] = entry
+}
+
+// Save writes the store back to disk, creating the cache directory if needed
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode App ID cache: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write App ID cache: %w", err)
+	}
+
+	return nil
+}
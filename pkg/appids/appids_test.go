@@ -0,0 +1,52 @@
+package appids
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	want := Entry{AppID: "108600", Title: "Better Sorting", Game: "Project Zomboid"}
+	s.Set("2503622437", want)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() unexpected error = %v", err)
+	}
+
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	if got, ok := s.Get("2503622437"); !ok || got != want {
+		t.Errorf("Get() = %+v, %v; want %+v", got, ok, want)
+	}
+	if _, ok := s.Get("1"); ok {
+		t.Error("Get() of an unknown item should report false")
+	}
+}
+
+func TestOpenReadsBareAppIDs(t *testing.T) {
+	// Earlier versions cached only the App ID
+	dir := t.TempDir()
+	data := `{"2503622437": "108600", "42": {"app_id": "4000", "title": "Some Map"}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	if got, _ := s.Get("2503622437"); got != (Entry{AppID: "108600"}) {
+		t.Errorf("Get() of a bare App ID = %+v, want only the App ID", got)
+	}
+	if got, _ := s.Get("42"); got != (Entry{AppID: "4000", Title: "Some Map"}) {
+		t.Errorf("Get() of an entry = %+v, want App ID 4000 and its title", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Package archive lists the entries of the archive formats workshop items ship
+// their content in, without extracting them.
+package archive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupported is returned for archive types that are recognized but can't be listed yet
+var ErrUnsupported = errors.New("archive type not supported")
+
+// Entry is one file inside an archive
+type Entry struct {
+	Name string // Slash-separated path inside the archive
+	Size int64  // Uncompressed size
+}
+
+// vpkChunk matches the numbered data files of a multi-part VPK (pak01_000.vpk),
+// which have no directory of their own; their entries are listed by pak01_dir.vpk
+var vpkChunk = regexp.MustCompile(`(?i)_\d{3}\.vpk$`)
+
+// Type returns the archive type of a file by its name: "vpk", "zip" or "pak",
+// or "" when it isn't an archive. VPK data chunks are reported as "".
+func Type(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".vpk":
+		if vpkChunk.MatchString(path) {
+			return ""
+		}
+		return "vpk"
+	case ".zip":
+		return "zip"
+	case ".pak":
+		return "pak"
+	}
+	return ""
+}
+
+// List returns the entries of the archive at path
+func List(path string) ([]Entry, error) {
+	switch Type(path) {
+	case "vpk":
+		return ListVPK(path)
+	case "zip":
+		return ListZip(path)
+	case "pak":
+		return nil, fmt.Errorf("%s: pak %w", filepath.Base(path), ErrUnsupported)
+	}
+	return nil, fmt.Errorf("%s: %w", filepath.Base(path), ErrUnsupported)
+}
+
+// ListZip returns the file entries of a zip archive
+func ListZip(path string) ([]Entry, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer r.Close()
+
+	var entries []Entry
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		entries = append(entries, Entry{Name: f.Name, Size: int64(f.UncompressedSize64)})
+	}
+	return entries, nil
+}
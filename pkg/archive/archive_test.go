@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeVPK writes a version 2 VPK directory file with the given tree, keyed
+// extension -> directory -> file name -> (preload, length)
+func writeVPK(t *testing.T, path string, tree map[string]map[string]map[string][2]int) {
+	t.Helper()
+
+	var body bytes.Buffer
+	cstring := func(s string) { body.WriteString(s); body.WriteByte(0) }
+	for ext, dirs := range tree {
+		cstring(ext)
+		for dir, files := range dirs {
+			cstring(dir)
+			for name, sizes := range files {
+				cstring(name)
+				binary.Write(&body, binary.LittleEndian, vpkEntry{
+					PreloadBytes: uint16(sizes[0]),
+					ArchiveIndex: 0x7fff,
+					EntryLength:  uint32(sizes[1]),
+					Terminator:   0xffff,
+				})
+				body.Write(make([]byte, sizes[0]))
+			}
+			cstring("")
+		}
+		cstring("")
+	}
+	cstring("")
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, vpkHeader{Signature: vpkSignature, Version: 2, TreeSize: uint32(body.Len())})
+	out.Write(make([]byte, 16))
+	out.Write(body.Bytes())
+
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListVPK(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pak01_dir.vpk")
+	writeVPK(t, path, map[string]map[string]map[string][2]int{
+		"vmt": {"materials/models": {"crate": {4, 100}}},
+		" ":   {" ": {"README": {0, 7}}},
+	})
+
+	entries, err := ListVPK(path)
+	if err != nil {
+		t.Fatalf("ListVPK() error = %v", err)
+	}
+
+	got := map[string]int64{}
+	for _, e := range entries {
+		got[e.Name] = e.Size
+	}
+	want := map[string]int64{"materials/models/crate.vmt": 104, "README": 7}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListVPK() = %v, want %v", got, want)
+	}
+}
+
+func TestListVPKRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fake.vpk")
+	os.WriteFile(path, []byte("PK\x03\x04 not a vpk at all"), 0644)
+
+	if _, err := ListVPK(path); err == nil {
+		t.Error("ListVPK() on a non-vpk file succeeded")
+	}
+}
+
+func TestListZip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mod.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	w.Create("scripts/")
+	fw, _ := w.Create("scripts/main.lua")
+	fw.Write([]byte("print('hi')"))
+	w.Close()
+	f.Close()
+
+	entries, err := List(path)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []Entry{{Name: "scripts/main.lua", Size: 11}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("List() = %v, want %v", entries, want)
+	}
+}
+
+func TestType(t *testing.T) {
+	tests := map[string]string{
+		"pak01_dir.vpk": "vpk",
+		"addon.VPK":     "vpk",
+		"pak01_000.vpk": "", // Data chunk, listed through pak01_dir.vpk
+		"mod.zip":       "zip",
+		"Content.pak":   "pak",
+		"readme.txt":    "",
+	}
+	for name, want := range tests {
+		if got := Type(name); got != want {
+			t.Errorf("Type(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
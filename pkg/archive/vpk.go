@@ -0,0 +1,134 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// vpkSignature starts the header of every VPK directory file
+const vpkSignature = 0x55aa1234
+
+// vpkHeader is the version 1 header; version 2 appends four section sizes
+type vpkHeader struct {
+	Signature uint32
+	Version   uint32
+	TreeSize  uint32
+}
+
+// vpkEntry follows each file name in the directory tree
+type vpkEntry struct {
+	CRC          uint32
+	PreloadBytes uint16
+	ArchiveIndex uint16
+	EntryOffset  uint32
+	EntryLength  uint32
+	Terminator   uint16
+}
+
+// ListVPK returns the file entries of a Valve VPK (version 1 or 2). For a
+// multi-part VPK pass the _dir.vpk file.
+func ListVPK(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vpk: %w", err)
+	}
+	defer f.Close()
+
+	return readVPK(bufio.NewReader(f))
+}
+
+func readVPK(r *bufio.Reader) ([]Entry, error) {
+	var header vpkHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read vpk header: %w", err)
+	}
+	if header.Signature != vpkSignature {
+		return nil, fmt.Errorf("not a vpk directory file (signature %#x)", header.Signature)
+	}
+	switch header.Version {
+	case 1:
+	case 2:
+		if _, err := r.Discard(16); err != nil {
+			return nil, fmt.Errorf("failed to read vpk header: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported vpk version %d", header.Version)
+	}
+
+	// The tree groups files by extension, then directory; a space stands for none
+	var entries []Entry
+	for {
+		ext, err := readCString(r)
+		if err != nil {
+			return nil, err
+		}
+		if ext == "" {
+			break
+		}
+
+		for {
+			dir, err := readCString(r)
+			if err != nil {
+				return nil, err
+			}
+			if dir == "" {
+				break
+			}
+
+			for {
+				name, err := readCString(r)
+				if err != nil {
+					return nil, err
+				}
+				if name == "" {
+					break
+				}
+
+				var entry vpkEntry
+				if err := binary.Read(r, binary.LittleEndian, &entry); err != nil {
+					return nil, fmt.Errorf("failed to read vpk entry %s: %w", name, err)
+				}
+				if entry.Terminator != 0xffff {
+					return nil, fmt.Errorf("corrupt vpk entry %s", name)
+				}
+				if _, err := r.Discard(int(entry.PreloadBytes)); err != nil {
+					return nil, fmt.Errorf("failed to read vpk entry %s: %w", name, err)
+				}
+
+				entries = append(entries, Entry{
+					Name: vpkPath(dir, name, ext),
+					Size: int64(entry.PreloadBytes) + int64(entry.EntryLength),
+				})
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// vpkPath joins the parts of a tree entry, where " " means an empty part
+func vpkPath(dir, name, ext string) string {
+	path := name
+	if ext != " " {
+		path += "." + ext
+	}
+	if dir != " " {
+		path = dir + "/" + path
+	}
+	return path
+}
+
+// readCString reads a NUL-terminated string
+func readCString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", fmt.Errorf("failed to read vpk tree: %w", err)
+	}
+	return s[:len(s)-1], nil
+}
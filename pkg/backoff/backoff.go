@@ -0,0 +1,88 @@
+package backoff
+
+import (
+	"time"
+
+	"github.com/sethvargo/go-retry"
+)
+
+// DefaultMaxRetries is the number of retries used when none is configured
+const DefaultMaxRetries uint64 = 10
+
+// BaseDelay is the first delay of the Fibonacci schedule
+const BaseDelay = 2 * time.Second
+
+// New returns the Fibonacci backoff shared by SteamCMD downloads and HTTP calls,
+// starting at BaseDelay and capped at maxRetries retries
+func New(maxRetries uint64) retry.Backoff {
+	b := retry.NewFibonacci(BaseDelay)
+	return retry.WithMaxRetries(maxRetries, b)
+}
+
+// Category groups failures that call for the same retry pace
+type Category string
+
+// Failure categories
+const (
+	CategoryNetwork   Category = "network"    // Connection blips, which usually clear up fast
+	CategoryServer    Category = "server"     // Server errors and failures without a clearer cause
+	CategoryRateLimit Category = "rate_limit" // Throttling, which only more waiting helps
+)
+
+// Categories lists every failure category
+var Categories = []Category{CategoryNetwork, CategoryServer, CategoryRateLimit}
+
+// Schedule is the Fibonacci delay sequence of a category, starting at Base
+// and never exceeding Max (0 for no cap)
+type Schedule struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Schedules are the schedules NewCategorized uses. The server schedule is
+// the plain Fibonacci of New.
+var Schedules = map[Category]Schedule{
+	CategoryNetwork:   {Base: time.Second, Max: 15 * time.Second},
+	CategoryServer:    {Base: BaseDelay},
+	CategoryRateLimit: {Base: 30 * time.Second, Max: 5 * time.Minute},
+}
+
+func (s Schedule) backoff() retry.Backoff {
+	base := s.Base
+	if base <= 0 {
+		base = BaseDelay
+	}
+	b := retry.NewFibonacci(base)
+	if s.Max > 0 {
+		b = retry.WithCappedDuration(s.Max, b)
+	}
+	return b
+}
+
+// NewCategorized returns a backoff capped at maxRetries retries in total whose
+// next delay follows the schedule of the category of the last failure, as
+// reported by category. Each category advances its own sequence, so a
+// connection blip after a rate limit is retried quickly again.
+func NewCategorized(maxRetries uint64, category func() Category) retry.Backoff {
+	sequences := make(map[Category]retry.Backoff)
+	var retries uint64
+
+	return retry.BackoffFunc(func() (time.Duration, bool) {
+		if retries >= maxRetries {
+			return 0, true
+		}
+		retries++
+
+		c := category()
+		schedule, ok := Schedules[c]
+		if !ok {
+			c, schedule = CategoryServer, Schedules[CategoryServer]
+		}
+		b, ok := sequences[c]
+		if !ok {
+			b = schedule.backoff()
+			sequences[c] = b
+		}
+		return b.Next()
+	})
+}
@@ -0,0 +1,48 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCategorized(t *testing.T) {
+	categories := []Category{
+		CategoryNetwork, CategoryNetwork,
+		CategoryRateLimit, CategoryRateLimit,
+		CategoryNetwork,
+		"unknown",
+	}
+	want := []time.Duration{
+		1 * time.Second, 2 * time.Second, // Network from its own base
+		30 * time.Second, 60 * time.Second, // Rate limits back off hard
+		3 * time.Second, // Network resumes its sequence
+		2 * time.Second, // Unknown categories use the server schedule
+	}
+
+	var i int
+	b := NewCategorized(uint64(len(categories)), func() Category { return categories[i] })
+	for i = range categories {
+		got, stop := b.Next()
+		if stop || got != want[i] {
+			t.Errorf("retry %d (%s): Next() = %v, %v; want %v", i+1, categories[i], got, stop, want[i])
+		}
+	}
+
+	if _, stop := b.Next(); !stop {
+		t.Errorf("Next() should stop after %d retries", len(categories))
+	}
+}
+
+func TestScheduleCap(t *testing.T) {
+	b := Schedule{Base: time.Second, Max: 4 * time.Second}.backoff()
+	var got []time.Duration
+	for range 6 {
+		d, _ := b.Next()
+		got = append(got, d)
+	}
+	for _, d := range got {
+		if d > 4*time.Second {
+			t.Fatalf("delays %v exceed the 4s cap", got)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// Package fsutil copies and moves directory trees the way downloaded workshop
+// items are put in place: files are written atomically, permissions can be
+// forced, and the files copied can be filtered.
+package fsutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Overwrite decides what happens to files that already exist at the destination
+type Overwrite int
+
+const (
+	OverwriteAlways Overwrite = iota // Replace existing files (the default)
+	OverwriteNever                   // Keep existing files
+	OverwriteNewer                   // Replace files older than the source
+)
+
+// Options controls CopyDir, CopyFile and MoveDir. The zero value copies
+// everything, keeping source modes and replacing existing files.
+type Options struct {
+	DirMode   os.FileMode // Mode for created directories; 0 keeps the default/source mode
+	FileMode  os.FileMode // Mode for copied files; 0 keeps the source mode
+	Durable   bool        // fsync each file before renaming it into place
+	Overwrite Overwrite
+
+	// Include limits CopyDir to files matching one of these globs; Exclude
+	// skips files and directories matching one. See MatchGlob.
+	Include []string
+	Exclude []string
+
+	// Progress, if set, is called after each file CopyDir copied with its
+	// slash-separated path relative to the source and its size
+	Progress func(rel string, size int64)
+
+	// CaseSafe makes CopyDir write files and directories whose paths differ
+	// from an earlier one only by case under a numbered name (name_2.ext), so
+	// they don't overwrite each other on case-insensitive file systems
+	CaseSafe bool
+
+	// OnCaseCollision, if set, is called for each path CopyDir found that
+	// differs from an earlier one only by case, with the slash-separated
+	// source paths of both and, with CaseSafe, the path it was written to
+	OnCaseCollision func(rel, earlier, renamed string)
+}
+
+// MatchGlob reports whether a path relative to a copied root matches pattern,
+// either as a whole or by its base name
+func MatchGlob(pattern, rel string) bool {
+	if ok, _ := filepath.Match(pattern, rel); ok {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, filepath.Base(rel))
+	return ok
+}
+
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// MkdirAll creates path and any missing parents. When DirMode is set it is
+// applied explicitly to every created directory so the umask can't narrow it.
+func (o Options) MkdirAll(path string, defaultMode os.FileMode) error {
+	if o.DirMode == 0 {
+		return os.MkdirAll(path, defaultMode)
+	}
+
+	// Remember which directories don't exist yet
+	var missing []string
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(path, o.DirMode); err != nil {
+		return err
+	}
+
+	for _, dir := range missing {
+		if err := os.Chmod(dir, o.DirMode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopyDir recursively copies the directory src to dst. Without Include every
+// directory is recreated, empty ones too; with it only those holding a
+// matching file are.
+func CopyDir(src, dst string, opts Options) error {
+	cases := newCaseIndex()
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		if rel != "." && matchesAny(opts.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		dstRel := rel
+		if rel != "." && (opts.CaseSafe || opts.OnCaseCollision != nil) {
+			var earlier string
+			dstRel, earlier = cases.place(rel, d.IsDir(), opts.CaseSafe)
+			if earlier != "" && opts.OnCaseCollision != nil {
+				renamed := ""
+				if dstRel != rel {
+					renamed = filepath.ToSlash(dstRel)
+				}
+				opts.OnCaseCollision(filepath.ToSlash(rel), filepath.ToSlash(earlier), renamed)
+			}
+		}
+		dstPath := filepath.Join(dst, dstRel)
+
+		if d.IsDir() {
+			if len(opts.Include) > 0 && rel != "." {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return opts.MkdirAll(dstPath, info.Mode().Perm())
+		}
+
+		if len(opts.Include) > 0 {
+			if !matchesAny(opts.Include, rel) {
+				return nil
+			}
+			if err := opts.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+				return err
+			}
+		}
+
+		copied, err := copyFile(path, dstPath, opts)
+		if err != nil {
+			return err
+		}
+		if copied >= 0 && opts.Progress != nil {
+			opts.Progress(filepath.ToSlash(rel), copied)
+		}
+		return nil
+	})
+}
+
+// caseIndex tracks the paths CopyDir writes, to find those that differ only
+// by case
+type caseIndex struct {
+	taken   map[string]string // Lowercased destination path -> source path written there
+	renamed map[string]string // Source directory -> destination, when it differs
+}
+
+func newCaseIndex() *caseIndex {
+	return &caseIndex{taken: make(map[string]string), renamed: make(map[string]string)}
+}
+
+// place returns where the source path rel goes, following renamed parent
+// directories, and the earlier source path it collides with by case, if any.
+// With rename, a colliding path gets the first free numbered name.
+func (c *caseIndex) place(rel string, isDir, rename bool) (dst, earlier string) {
+	parent, name := filepath.Dir(rel), filepath.Base(rel)
+	if renamed, ok := c.renamed[parent]; ok {
+		parent = renamed
+	}
+	dst = filepath.Join(parent, name)
+
+	earlier, collides := c.taken[strings.ToLower(dst)]
+	if collides && rename {
+		ext := ""
+		if !isDir {
+			ext = filepath.Ext(name)
+		}
+		stem := strings.TrimSuffix(name, ext)
+		for n := 2; ; n++ {
+			candidate := filepath.Join(parent, fmt.Sprintf("%s_%d%s", stem, n, ext))
+			if _, ok := c.taken[strings.ToLower(candidate)]; !ok {
+				dst = candidate
+				break
+			}
+		}
+	}
+
+	c.taken[strings.ToLower(dst)] = rel
+	if isDir && dst != rel {
+		c.renamed[rel] = dst
+	}
+	return dst, earlier
+}
+
+// CopyFile copies a single file from src to dst. The content is written to a
+// temporary file next to dst and renamed into place only once complete, so a
+// failure mid-copy never leaves a truncated file that looks valid.
+func CopyFile(src, dst string, opts Options) error {
+	_, err := copyFile(src, dst, opts)
+	return err
+}
+
+// copyBufferSize is the buffer used when copying file contents
+const copyBufferSize = 1024 * 1024
+
+// copyFile copies src to dst and returns the bytes copied, or -1 when the
+// overwrite policy kept an existing dst
+func copyFile(src, dst string, opts Options) (int64, error) {
+	// Open source file
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer srcFile.Close()
+
+	// Get source file info
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	if dstInfo, err := os.Stat(dst); err == nil {
+		switch opts.Overwrite {
+		case OverwriteNever:
+			return -1, nil
+		case OverwriteNewer:
+			if !srcInfo.ModTime().After(dstInfo.ModTime()) {
+				return -1, nil
+			}
+		}
+	}
+
+	// Create a temporary file in the destination directory
+	tmpFile, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := tmpFile.Name()
+
+	if err := writeTempFile(tmpFile, srcFile, opts.Durable); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+
+	// Set the file permissions to match the source, unless overridden
+	mode := srcInfo.Mode()
+	if opts.FileMode != 0 {
+		mode = opts.FileMode
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("failed to move %s into place: %w", dst, err)
+	}
+
+	return srcInfo.Size(), nil
+}
+
+// writeTempFile copies r into f and closes it, syncing to disk first when durable
+func writeTempFile(f *os.File, r io.Reader, durable bool) error {
+	buf := make([]byte, copyBufferSize)
+	if _, err := io.CopyBuffer(f, r, buf); err != nil {
+		f.Close()
+		return err
+	}
+
+	if durable {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	return f.Close()
+}
+
+// MoveDir renames src to dst, copying when they're on different filesystems
+func MoveDir(src, dst string, opts Options) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	if err := CopyDir(src, dst, opts); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
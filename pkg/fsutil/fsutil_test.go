@@ -0,0 +1,147 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTree writes files under root in sorted order, so runs don't differ
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	rels := make([]string, 0, len(files))
+	for rel := range files {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		content := files[rel]
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{
+		"mod.info":           "info",
+		"media/lua/main.lua": "lua",
+		"media/preview.psd":  "psd",
+		".git/HEAD":          "ref",
+	})
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"everything", Options{}, []string{".git/HEAD", "media/lua/main.lua", "media/preview.psd", "mod.info"}},
+		{"include", Options{Include: []string{"*.lua", "mod.info"}}, []string{"media/lua/main.lua", "mod.info"}},
+		{"exclude", Options{Exclude: []string{".git", "*.psd"}}, []string{"media/lua/main.lua", "mod.info"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "out")
+
+			var got []string
+			tt.opts.Progress = func(rel string, size int64) { got = append(got, rel) }
+			if err := CopyDir(src, dst, tt.opts); err != nil {
+				t.Fatalf("CopyDir() unexpected error = %v", err)
+			}
+
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("CopyDir() copied %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("CopyDir() copied %v, want %v", got, tt.want)
+				}
+				if _, err := os.Stat(filepath.Join(dst, filepath.FromSlash(got[i]))); err != nil {
+					t.Errorf("%s reported but not copied: %v", got[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyDirCaseSafe(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{
+		"Texture.png":    "upper",
+		"texture.png":    "lower",
+		"Textures/a.png": "a",
+		"textures/b.png": "b",
+	})
+
+	var collisions []string
+	opts := Options{
+		CaseSafe: true,
+		OnCaseCollision: func(rel, earlier, renamed string) {
+			collisions = append(collisions, rel+" ~ "+earlier+" -> "+renamed)
+		},
+	}
+	dst := t.TempDir()
+	if err := CopyDir(src, dst, opts); err != nil {
+		t.Fatalf("CopyDir() unexpected error = %v", err)
+	}
+
+	wantCollisions := []string{"texture.png ~ Texture.png -> texture_2.png", "textures ~ Textures -> textures_2"}
+	if strings.Join(collisions, "\n") != strings.Join(wantCollisions, "\n") {
+		t.Errorf("collisions = %q, want %q", collisions, wantCollisions)
+	}
+	for rel, want := range map[string]string{
+		"Texture.png":      "upper",
+		"texture_2.png":    "lower",
+		"Textures/a.png":   "a",
+		"textures_2/b.png": "b",
+	} {
+		if data, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(rel))); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", rel, data, err, want)
+		}
+	}
+}
+
+func TestCopyFileOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	writeTree(t, dir, map[string]string{"src.txt": "new", "dst.txt": "old"})
+
+	// Files written back to back can share a timestamp, so set them apart
+	srcTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(src, srcTime, srcTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(dst, srcTime.Add(time.Minute), srcTime.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		overwrite Overwrite
+		want      string
+	}{
+		{OverwriteNever, "old"},
+		{OverwriteNewer, "old"}, // dst was written after src
+		{OverwriteAlways, "new"},
+	}
+
+	for _, tt := range tests {
+		if err := CopyFile(src, dst, Options{Overwrite: tt.overwrite}); err != nil {
+			t.Fatalf("CopyFile() unexpected error = %v", err)
+		}
+		if data, _ := os.ReadFile(dst); string(data) != tt.want {
+			t.Errorf("CopyFile() with overwrite %d left %q, want %q", tt.overwrite, data, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/backoff"
+	"github.com/sethvargo/go-retry"
+)
+
+// MaxRetries is the number of retries used by clients created with New
+var MaxRetries = backoff.DefaultMaxRetries
+
+// InsecureSkipVerify makes clients created with New accept any TLS
+// certificate. It exists for networks whose proxies intercept TLS with their
+// own certificates, and leaves every request open to tampering.
+var InsecureSkipVerify bool
+
+// Base, if set, is the client New builds on instead of a plain one, so every
+// request shares its transport, cookie jar and redirect policy: a custom
+// transport or proxy, or a stub in tests. New still sets the timeout it is
+// given. InsecureSkipVerify only applies to an *http.Transport (or none).
+var Base *http.Client
+
+// Client performs HTTP requests with retry/backoff on transient failures
+type Client struct {
+	HTTP       *http.Client
+	MaxRetries uint64
+}
+
+// New creates a retrying HTTP client with the given timeout (0 means no timeout)
+func New(timeout time.Duration) *Client {
+	httpClient := &http.Client{}
+	if Base != nil {
+		*httpClient = *Base
+	}
+	httpClient.Timeout = timeout
+
+	if InsecureSkipVerify {
+		base := httpClient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		if transport, ok := base.(*http.Transport); ok {
+			transport = transport.Clone()
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+			transport.TLSClientConfig.InsecureSkipVerify = true
+			httpClient.Transport = transport
+		}
+	}
+
+	return &Client{
+		HTTP:       httpClient,
+		MaxRetries: MaxRetries,
+	}
+}
+
+// maxRetryAfter caps the delay a server can request with Retry-After
+const maxRetryAfter = 5 * time.Minute
+
+// StatusError is returned when the server answers with a non-200 status
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("bad status: %s", e.Status)
+}
+
+// Get fetches url, retrying on connection errors, 429 and 5xx responses, and
+// honoring Retry-After. Other non-200 responses (e.g. 404) fail immediately
+// with a *StatusError.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	return c.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	})
+}
+
+// PostForm posts form values to url with the same retry behavior as Get
+func (c *Client) PostForm(ctx context.Context, url string, values neturl.Values) (*http.Response, error) {
+	return c.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(values.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	})
+}
+
+// do sends the request built by newRequest, rebuilding it for every attempt
+func (c *Client) do(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, error) {
+	// Wait at least as long as the server asked in Retry-After before the next attempt
+	var retryAfter time.Duration
+	category := backoff.CategoryServer
+	base := backoff.NewCategorized(c.MaxRetries, func() backoff.Category { return category })
+	b := retry.BackoffFunc(func() (time.Duration, bool) {
+		delay, stop := base.Next()
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		retryAfter = 0
+		return delay, stop
+	})
+
+	var resp *http.Response
+	err := retry.Do(ctx, b, func(ctx context.Context) error {
+		req, err := newRequest(ctx)
+		if err != nil {
+			return err
+		}
+
+		r, err := c.HTTP.Do(req)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			// Connection errors and timeouts are worth another try
+			category = backoff.CategoryNetwork
+			return retry.RetryableError(err)
+		}
+
+		if r.StatusCode == http.StatusOK {
+			resp = r
+			return nil
+		}
+		r.Body.Close()
+
+		statusErr := &StatusError{StatusCode: r.StatusCode, Status: r.Status}
+		if r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= 500 {
+			category = backoff.CategoryServer
+			if r.StatusCode == http.StatusTooManyRequests {
+				category = backoff.CategoryRateLimit
+			}
+			retryAfter = parseRetryAfter(r.Header.Get("Retry-After"), time.Now())
+			return retry.RetryableError(statusErr)
+		}
+		return statusErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header, given
+// either in seconds or as an HTTP date. It returns 0 when there's none.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		delay = at.Sub(now)
+	}
+
+	if delay < 0 {
+		return 0
+	}
+	return min(delay, maxRetryAfter)
+}
@@ -0,0 +1,90 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{" 5 ", 5 * time.Second},
+		{"-1", 0},
+		{"Wed, 01 Jan 2025 12:00:45 GMT", 45 * time.Second},
+		{"Wed, 01 Jan 2025 11:00:00 GMT", 0},
+		{"86400", maxRetryAfter},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	defer func(v bool) { InsecureSkipVerify = v }(InsecureSkipVerify)
+
+	InsecureSkipVerify = false
+	client := New(5 * time.Second)
+	client.MaxRetries = 0
+	if _, err := client.Get(context.Background(), server.URL); err == nil {
+		t.Fatal("Get accepted a self-signed certificate without InsecureSkipVerify")
+	}
+
+	InsecureSkipVerify = true
+	resp, err := New(5*time.Second).Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Get with InsecureSkipVerify: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// roundTripFunc stubs a transport
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBase(t *testing.T) {
+	defer func(c *http.Client) { Base = c }(Base)
+
+	var requested string
+	Base = &http.Client{
+		Timeout: time.Minute,
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requested = req.URL.String()
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		}),
+	}
+
+	client := New(5 * time.Second)
+	if client.HTTP.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want the one given to New", client.HTTP.Timeout)
+	}
+	resp, err := client.Get(context.Background(), "https://example.invalid/page")
+	if err != nil {
+		t.Fatalf("Get through Base's transport: %v", err)
+	}
+	resp.Body.Close()
+	if requested != "https://example.invalid/page" {
+		t.Errorf("Base's transport got %q, want the requested URL", requested)
+	}
+	if Base.Timeout != time.Minute {
+		t.Errorf("New modified Base: Timeout = %v", Base.Timeout)
+	}
+}
@@ -0,0 +1,111 @@
+// Package logfile writes a log file that is rotated by size: once a write
+// would take it past the limit, log becomes log.1, log.1 becomes log.2 and
+// so on, and the oldest backup beyond the limit is deleted.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Writer appends to a log file, rotating it by size. It is safe for
+// concurrent use within one process; processes sharing a file may rotate it
+// under each other.
+type Writer struct {
+	Path       string
+	MaxSize    int64 // Rotate before the file grows past this many bytes; 0 never rotates
+	MaxBackups int   // Rotated files kept as Path.1 (newest) to Path.N
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open opens path for appending, creating it and its directory if needed
+func Open(path string, maxSize int64, maxBackups int) (*Writer, error) {
+	w := &Writer{Path: path, MaxSize: maxSize, MaxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first when p would take it past
+// MaxSize. A single write larger than MaxSize still goes to one file.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups up by one and starts a new, empty file
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	if w.MaxBackups > 0 {
+		os.Remove(backupPath(w.Path, w.MaxBackups))
+		for n := w.MaxBackups - 1; n >= 1; n-- {
+			os.Rename(backupPath(w.Path, n), backupPath(w.Path, n+1))
+		}
+		if err := os.Rename(w.Path, backupPath(w.Path, 1)); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(w.Path); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	return w.open()
+}
+
+// Close closes the file; later writes fail
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// backupPath is the name of the nth most recent rotated file
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
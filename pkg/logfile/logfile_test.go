@@ -0,0 +1,48 @@
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "workshop.log")
+	w, err := Open(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) unexpected error = %v", line, err)
+		}
+	}
+
+	// Each line fills more than half the limit, so each got its own file and
+	// the oldest fell off
+	for file, want := range map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	} {
+		if data, err := os.ReadFile(file); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", filepath.Base(file), data, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists beyond MaxBackups", filepath.Base(path))
+	}
+
+	// Reopening picks up the size of the existing file
+	w.Close()
+	w, err = Open(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Open() unexpected error = %v", err)
+	}
+	w.Write([]byte("fifth\n"))
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "fourth\n" {
+		t.Errorf("after reopening, %s.1 = %q, want the previous file", filepath.Base(path), data)
+	}
+}
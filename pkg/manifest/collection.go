@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// flexID is an ID that may be written as a JSON number or string
+type flexID string
+
+func (id *flexID) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = flexID(s)
+		return nil
+	}
+
+	// Keep numbers as text so large 64-bit IDs don't lose precision
+	if _, err := strconv.ParseUint(string(data), 10, 64); err != nil {
+		return fmt.Errorf("invalid ID %s", data)
+	}
+	*id = flexID(data)
+	return nil
+}
+
+// collectionEntry is one item of a collection export: either a bare ID or an object
+type collectionEntry struct {
+	AppID      flexID
+	WorkshopID flexID
+}
+
+func (e *collectionEntry) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return e.WorkshopID.UnmarshalJSON(data)
+	}
+
+	var obj struct {
+		AppID           flexID `json:"appid"`
+		AppIDAlt        flexID `json:"app_id"`
+		WorkshopID      flexID `json:"workshopid"`
+		WorkshopIDAlt   flexID `json:"workshop_id"`
+		ID              flexID `json:"id"`
+		PublishedFileID flexID `json:"publishedfileid"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	e.AppID = firstNonEmpty(obj.AppID, obj.AppIDAlt)
+	e.WorkshopID = firstNonEmpty(obj.WorkshopID, obj.WorkshopIDAlt, obj.ID, obj.PublishedFileID)
+	return nil
+}
+
+func firstNonEmpty(ids ...flexID) flexID {
+	for _, id := range ids {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// LoadCollectionJSON reads a collection exported as JSON. Two layouts are accepted:
+//
+//	{"appid": 108600, "items": [2503622437, "2503622438"]}
+//	[{"appid": 108600, "workshopid": 2503622437}, ...]
+//
+// Items inside the object form may also be objects overriding the top-level appid.
+func LoadCollectionJSON(path string) ([]Item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection: %w", err)
+	}
+
+	var entries []collectionEntry
+	var defaultAppID flexID
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse collection: %w", err)
+		}
+	} else {
+		var obj struct {
+			AppID    flexID            `json:"appid"`
+			AppIDAlt flexID            `json:"app_id"`
+			Items    []collectionEntry `json:"items"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("failed to parse collection: %w", err)
+		}
+		entries = obj.Items
+		defaultAppID = firstNonEmpty(obj.AppID, obj.AppIDAlt)
+	}
+
+	items := make([]Item, 0, len(entries))
+	var problems []Problem
+	for i, entry := range entries {
+		appID := firstNonEmpty(entry.AppID, defaultAppID)
+		if appID == "" {
+			problems = append(problems, Problem{Field: fmt.Sprintf("items[%d].appid", i), Message: "required"})
+		}
+		if entry.WorkshopID == "" {
+			problems = append(problems, Problem{Field: fmt.Sprintf("items[%d].workshopid", i), Message: "required"})
+		}
+		items = append(items, Item{AppID: string(appID), WorkshopID: string(entry.WorkshopID)})
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Path: path, Problems: problems}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,64 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// HashDir returns a SHA-256 over the contents of dir that only depends on the
+// relative paths and bytes of its files, so identical content hashes the same
+// on every machine
+func HashDir(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	sum := sha256.New()
+	for _, path := range files {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return "", err
+		}
+
+		fileSum, err := HashFile(path)
+		if err != nil {
+			return "", err
+		}
+		// One line per file, like sha256sum output
+		fmt.Fprintf(sum, "%s  %s\n", fileSum, filepath.ToSlash(rel))
+	}
+
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// HashFile returns the hex SHA-256 of the file at path
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
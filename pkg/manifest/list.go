@@ -0,0 +1,82 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadItemList reads a plain-text item list: one "<app id> <workshop id>"
+// pair per line. Blank lines and lines starting with # are ignored.
+func LoadItemList(path string) ([]Item, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read item list: %w", err)
+	}
+	defer f.Close()
+
+	var items []Item
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 || !isID(fields[0]) || !isID(fields[1]) {
+			return nil, fmt.Errorf("%s:%d: expected \"<app id> <workshop id>\", got %q", path, line, text)
+		}
+		items = append(items, Item{AppID: fields[0], WorkshopID: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read item list: %w", err)
+	}
+
+	return items, nil
+}
+
+// SaveItemList writes items in the format LoadItemList reads, after a
+// comment line with header if it's set. The list is written next to path and
+// renamed over it, so a run interrupted while saving leaves the previous
+// list, which may be the one the run was reading, intact.
+func SaveItemList(path, header string, items []Item) error {
+	var sb strings.Builder
+	if header != "" {
+		fmt.Fprintf(&sb, "# %s\n", header)
+	}
+	for _, item := range items {
+		fmt.Fprintf(&sb, "%s %s\n", item.AppID, item.WorkshopID)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write item list: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.WriteString(sb.String())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write item list: %w", err)
+	}
+	return nil
+}
+
+// isID reports whether s is a numeric Steam ID
+func isID(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
@@ -0,0 +1,113 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestItemListRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failed.txt")
+	items := []Item{{AppID: "108600", WorkshopID: "2503622437"}, {AppID: "4000", WorkshopID: "123"}}
+
+	if err := SaveItemList(path, "2 failed item(s)", items); err != nil {
+		t.Fatalf("SaveItemList() unexpected error = %v", err)
+	}
+	got, err := LoadItemList(path)
+	if err != nil {
+		t.Fatalf("LoadItemList() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(got, items) {
+		t.Errorf("LoadItemList() = %v, want %v", got, items)
+	}
+}
+
+func TestSaveItemListReplaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "items.txt")
+	if err := os.WriteFile(path, []byte("108600 1\n108600 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving over the list being read replaces it whole
+	items := []Item{{AppID: "108600", WorkshopID: "2"}}
+	if err := SaveItemList(path, "", items); err != nil {
+		t.Fatalf("SaveItemList() unexpected error = %v", err)
+	}
+	if got, err := LoadItemList(path); err != nil || !reflect.DeepEqual(got, items) {
+		t.Errorf("LoadItemList() = %v, %v; want %v", got, err, items)
+	}
+
+	// A failed save leaves no temporary file behind
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveItemList(blocked, "", items); err == nil {
+		t.Error("SaveItemList() over a non-empty directory expected an error")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %v, want only items.txt and blocked", names)
+	}
+}
+
+func TestLoadItemListErrors(t *testing.T) {
+	for _, content := range []string{"108600\n", "108600 abc\n", "108600 1 2\n", "-1 5\n"} {
+		path := filepath.Join(t.TempDir(), "list.txt")
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := LoadItemList(path); err == nil {
+			t.Errorf("LoadItemList(%q) expected an error", content)
+		}
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mods.yaml")
+	os.WriteFile(path, []byte("items:\n  - app_id: 108600\n    workshop_id: 2503622437\n    size_bytes: 12\n  - app_id: \"4000\"\n    workshop_id: \"123\"\n"), 0644)
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	want := []Item{{AppID: "108600", WorkshopID: "2503622437", SizeBytes: 12}, {AppID: "4000", WorkshopID: "123"}}
+	if !reflect.DeepEqual(m.Items, want) {
+		t.Errorf("Load() items = %v, want %v", m.Items, want)
+	}
+}
+
+func TestLoadManifestProblems(t *testing.T) {
+	_, err := Load("testdata/invalid.yaml")
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Load() error = %v, want a *ValidationError", err)
+	}
+
+	want := []string{
+		`line 5: items[1].workshop_id: "abc" is not a numeric Steam ID`,
+		`line 6: items[2].app_id: required`,
+		`line 7: items[2].sha256: "not-a-hash" is not a lowercase hex SHA-256 digest`,
+		`line 8: items[3]: duplicate of the item on line 2 (app 108600, workshop item 2503622437)`,
+		`line 10: items[3].size: unknown field`,
+		`line 11: items[4]: expected a mapping with app_id and workshop_id`,
+		`line 12: version: unknown field`,
+	}
+	var got []string
+	for _, p := range verr.Problems {
+		got = append(got, p.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
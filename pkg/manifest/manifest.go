@@ -0,0 +1,71 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Item is a single workshop item in a manifest
+type Item struct {
+	AppID      string `yaml:"app_id"`
+	WorkshopID string `yaml:"workshop_id"`
+	Title      string `yaml:"title,omitempty"`
+
+	// Version information, recorded in lockfiles written with --manifest-out
+	ManifestID string `yaml:"manifest_id,omitempty"`
+	SizeBytes  int64  `yaml:"size_bytes,omitempty"`
+	SHA256     string `yaml:"sha256,omitempty"`
+}
+
+// Manifest is a shareable list of workshop items, e.g. a server's mod set
+type Manifest struct {
+	Items []Item `yaml:"items"`
+}
+
+// Load reads a manifest from a YAML file. A manifest that parses but has
+// missing, malformed, unknown or duplicate entries fails with a
+// *ValidationError listing all of them.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if problems := validateManifest(&doc); len(problems) > 0 {
+		return nil, &ValidationError{Path: path, Problems: problems}
+	}
+
+	var m Manifest
+	if doc.Kind == yaml.DocumentNode {
+		if err := doc.Decode(&m); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+		}
+	}
+
+	return &m, nil
+}
+
+// Marshal encodes the manifest as YAML
+func (m *Manifest) Marshal() ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// Save writes the manifest to a YAML file
+func (m *Manifest) Save(path string) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is one thing wrong with a manifest. Line is 0 when the format
+// doesn't track lines, e.g. for JSON collections.
+type Problem struct {
+	Line    int
+	Field   string
+	Message string
+}
+
+func (p Problem) String() string {
+	var sb strings.Builder
+	if p.Line > 0 {
+		fmt.Fprintf(&sb, "line %d: ", p.Line)
+	}
+	if p.Field != "" {
+		fmt.Fprintf(&sb, "%s: ", p.Field)
+	}
+	sb.WriteString(p.Message)
+	return sb.String()
+}
+
+// ValidationError lists every problem found in a manifest, so a hand-edited
+// file can be fixed in one pass
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d problem(s):", e.Path, len(e.Problems))
+	for _, p := range e.Problems {
+		fmt.Fprintf(&sb, "\n  %s", p)
+	}
+	return sb.String()
+}
+
+// sha256Pattern matches a hex-encoded SHA-256 digest
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// itemFields validates the value of each field an item may have
+var itemFields = map[string]func(value string) string{
+	"app_id":      validateID,
+	"workshop_id": validateID,
+	"title":       func(string) string { return "" },
+	"manifest_id": validateID,
+	"size_bytes": func(value string) string {
+		if n, err := strconv.ParseInt(value, 10, 64); err != nil || n < 0 {
+			return fmt.Sprintf("%q is not a size in bytes", value)
+		}
+		return ""
+	},
+	"sha256": func(value string) string {
+		if !sha256Pattern.MatchString(value) {
+			return fmt.Sprintf("%q is not a lowercase hex SHA-256 digest", value)
+		}
+		return ""
+	},
+}
+
+// requiredItemFields are the fields every item must set
+var requiredItemFields = []string{"app_id", "workshop_id"}
+
+func validateID(value string) string {
+	if !isID(value) {
+		return fmt.Sprintf("%q is not a numeric Steam ID", value)
+	}
+	return ""
+}
+
+// validateManifest checks the document of a YAML manifest and returns every
+// problem found, with the line it's on, in file order
+func validateManifest(doc *yaml.Node) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, field, format string, args ...any) {
+		problems = append(problems, Problem{Line: node.Line, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		// An empty file is an empty manifest
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		add(root, "", "expected a mapping with an items list")
+		return problems
+	}
+
+	var items *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "items" {
+			add(key, key.Value, "unknown field")
+			continue
+		}
+		items = value
+	}
+	if items == nil {
+		return problems
+	}
+	if items.Kind != yaml.SequenceNode {
+		if items.Tag != "!!null" {
+			add(items, "items", "expected a list of items")
+		}
+		return problems
+	}
+
+	// Duplicates are reported against the item that first listed the pair
+	firstLine := make(map[[2]string]int)
+	for i, item := range items.Content {
+		prefix := fmt.Sprintf("items[%d]", i)
+		if item.Kind != yaml.MappingNode {
+			add(item, prefix, "expected a mapping with app_id and workshop_id")
+			continue
+		}
+
+		values := make(map[string]string)
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			key, value := item.Content[j], item.Content[j+1]
+			field := prefix + "." + key.Value
+
+			validate, known := itemFields[key.Value]
+			switch {
+			case !known:
+				add(key, field, "unknown field")
+				continue
+			case hasKey(values, key.Value):
+				add(key, field, "set more than once")
+				continue
+			case value.Kind != yaml.ScalarNode:
+				add(value, field, "expected a single value")
+				continue
+			}
+
+			values[key.Value] = value.Value
+			if msg := validate(value.Value); msg != "" {
+				add(value, field, "%s", msg)
+			}
+		}
+
+		missing := false
+		for _, name := range requiredItemFields {
+			if !hasKey(values, name) {
+				add(item, prefix+"."+name, "required")
+				missing = true
+			}
+		}
+		if missing {
+			continue
+		}
+
+		pair := [2]string{values["app_id"], values["workshop_id"]}
+		if line, ok := firstLine[pair]; ok {
+			add(item, prefix, "duplicate of the item on line %d (app %s, workshop item %s)", line, pair[0], pair[1])
+			continue
+		}
+		firstLine[pair] = item.Line
+	}
+
+	// Report in file order
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
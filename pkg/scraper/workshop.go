@@ -1,48 +1,76 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"html"
+	"io"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
 )
 
+// ErrAppIDNotFound is returned when the page was fetched but no App ID could be
+// found on it. The returned WorkshopInfo still carries the workshop ID and title.
+var ErrAppIDNotFound = errors.New("could not extract App ID from workshop page")
+
+// maxPageSize caps how much of a workshop page is read
+const maxPageSize = 1024 * 1024
+
 // WorkshopInfo contains information scraped from a workshop page
 type WorkshopInfo struct {
 	AppID      string
 	WorkshopID string
 	Title      string
 	GameName   string
+
+	// Dependencies are the items listed under "Required items", in page order
+	Dependencies []Dependency
+}
+
+// Dependency is a workshop item another one requires
+type Dependency struct {
+	WorkshopID string
+	Title      string
+}
+
+// ItemURL returns the workshop page of an item
+func ItemURL(workshopID string) string {
+	return "https://steamcommunity.com/sharedfiles/filedetails/?id=" + workshopID
 }
 
 // ScrapeWorkshopPage extracts App ID and other info from a Steam Workshop URL
 func ScrapeWorkshopPage(url string) (*WorkshopInfo, error) {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	// Create HTTP client with timeout and retry on transient failures
+	client := httpclient.New(10 * time.Second)
 
 	// Make request to workshop page
-	resp, err := client.Get(url)
+	resp, err := client.Get(context.Background(), url)
 	if err != nil {
+		var statusErr *httpclient.StatusError
+		if errors.As(err, &statusErr) {
+			return nil, fmt.Errorf("workshop page returned status: %s", statusErr.Status)
+		}
 		return nil, fmt.Errorf("failed to fetch workshop page: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("workshop page returned status: %s", resp.Status)
-	}
-
 	// Read the page content
-	buf := make([]byte, 1024*1024) // Read up to 1MB
-	n, err := resp.Body.Read(buf)
-	if err != nil && n == 0 {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil && len(body) == 0 {
 		return nil, fmt.Errorf("failed to read workshop page content: %w", err)
 	}
 
-	content := string(buf[:n])
+	return ParseWorkshopHTML(string(body), url)
+}
 
+// ParseWorkshopHTML extracts App ID and other info from the HTML of a workshop
+// page that was already fetched, e.g. saved from a browser. url is the page's
+// address, which carries the workshop ID.
+func ParseWorkshopHTML(content, url string) (*WorkshopInfo, error) {
 	// Extract workshop ID from URL
 	workshopIDRegex := regexp.MustCompile(`id=(\d+)`)
 	workshopIDMatches := workshopIDRegex.FindStringSubmatch(url)
@@ -74,39 +102,77 @@ func ScrapeWorkshopPage(url string) (*WorkshopInfo, error) {
 		}
 	}
 
-	if info.AppID == "" {
-		return nil, fmt.Errorf("could not extract App ID from workshop page")
-	}
-
 	// Extract title if possible
 	titleRegex := regexp.MustCompile(`<title>([^<]+)</title>`)
 	titleMatches := titleRegex.FindStringSubmatch(content)
 	if len(titleMatches) > 1 {
-		info.Title = strings.TrimSpace(titleMatches[1])
+		info.Title = cleanText(titleMatches[1])
 		// Remove "Steam Workshop::" prefix if present
 		info.Title = strings.TrimPrefix(info.Title, "Steam Workshop::")
 		info.Title = strings.TrimSpace(info.Title)
 	}
 
-	// Try to extract game name
+	// Try to extract game name from the app hub header or the breadcrumbs.
+	// The <title> names the item, not the game.
 	gameNamePatterns := []string{
-		`Steam Workshop::\s*([^>]+)`,
-		`<h1[^>]*class="apphub_AppName"[^>]*>([^<]+)</h1>`,
-		`data-panel="\{\\"appName\\":\\"([^"]+)\\"`,
+		`class="apphub_AppName[^"]*"[^>]*>([^<]+)<`,       // App hub header
+		`class="breadcrumbs"[^>]*>\s*<a[^>]*>([^<]+)</a>`, // First breadcrumb
+		`data-panel="\{\\"appName\\":\\"([^"]+)\\"`,       // Panel data
 	}
 
 	for _, pattern := range gameNamePatterns {
 		regex := regexp.MustCompile(pattern)
 		matches := regex.FindStringSubmatch(content)
 		if len(matches) > 1 {
-			info.GameName = strings.TrimSpace(matches[1])
+			info.GameName = cleanText(matches[1])
 			break
 		}
 	}
 
+	info.Dependencies = parseRequiredItems(content)
+
+	if info.AppID == "" {
+		return info, ErrAppIDNotFound
+	}
+
 	return info, nil
 }
 
+// requiredItemRegex matches one linked item at the start of the "Required
+// items" container
+var requiredItemRegex = regexp.MustCompile(`^\s*<a[^>]*href="[^"]*[?&]id=(\d+)"[^>]*>\s*<div class="requiredItem">([^<]*)</div>\s*</a>`)
+
+// parseRequiredItems extracts the items of the page's "Required items" section.
+// Required DLC is listed in a separate container and isn't included.
+func parseRequiredItems(content string) []Dependency {
+	start := strings.Index(content, `id="RequiredItems"`)
+	if start < 0 {
+		return nil
+	}
+	rest := content[start:]
+	rest = rest[strings.Index(rest, ">")+1:]
+
+	var deps []Dependency
+	for {
+		match := requiredItemRegex.FindStringSubmatch(rest)
+		if match == nil {
+			return deps
+		}
+		deps = append(deps, Dependency{WorkshopID: match[1], Title: cleanText(match[2])})
+		rest = rest[len(match[0]):]
+	}
+}
+
+// cleanText turns text scraped from HTML into plain text: entities such as
+// &amp; or &#1234; are decoded, byte order marks dropped, invalid UTF-8
+// replaced and runs of whitespace collapsed
+func cleanText(s string) string {
+	s = html.UnescapeString(s)
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	s = strings.ReplaceAll(s, "\uFEFF", "")
+	return strings.Join(strings.Fields(s), " ")
+}
+
 // GetAppIDFromWorkshopURL is a convenience function to just get the App ID
 func GetAppIDFromWorkshopURL(url string) (string, error) {
 	info, err := ScrapeWorkshopPage(url)
@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
+)
+
+func TestParseWorkshopHTML(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "workshop_item.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ParseWorkshopHTML(string(data), "https://steamcommunity.com/sharedfiles/filedetails/?id=2503622437")
+	if err != nil {
+		t.Fatalf("ParseWorkshopHTML() unexpected error = %v", err)
+	}
+
+	if info.AppID != "108600" {
+		t.Errorf("AppID = %q, want %q", info.AppID, "108600")
+	}
+	if info.WorkshopID != "2503622437" {
+		t.Errorf("WorkshopID = %q, want %q", info.WorkshopID, "2503622437")
+	}
+	if want := `Better Sorting, Quotes "Edition"`; info.Title != want {
+		t.Errorf("Title = %q, want %q", info.Title, want)
+	}
+	if want := "Project Zomboid"; info.GameName != want {
+		t.Errorf("GameName = %q, want %q", info.GameName, want)
+	}
+
+	// Required DLC isn't a workshop dependency
+	wantDeps := []Dependency{{"2392709985", "Tsar's Common Library"}, {"2169435993", "ModOptions"}}
+	if len(info.Dependencies) != len(wantDeps) {
+		t.Fatalf("Dependencies = %+v, want %+v", info.Dependencies, wantDeps)
+	}
+	for i, dep := range info.Dependencies {
+		if dep != wantDeps[i] {
+			t.Errorf("Dependencies[%d] = %+v, want %+v", i, dep, wantDeps[i])
+		}
+	}
+}
+
+func TestScrapeWorkshopPage(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "workshop_item.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "2503622437" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	defer func(c *http.Client) { httpclient.Base = c }(httpclient.Base)
+	httpclient.Base = server.Client()
+
+	info, err := ScrapeWorkshopPage(server.URL + "/sharedfiles/filedetails/?id=2503622437")
+	if err != nil {
+		t.Fatalf("ScrapeWorkshopPage() unexpected error = %v", err)
+	}
+	if info.AppID != "108600" || info.WorkshopID != "2503622437" {
+		t.Errorf("ScrapeWorkshopPage() = %+v, want app 108600, item 2503622437", info)
+	}
+
+	_, err = ScrapeWorkshopPage(server.URL + "/sharedfiles/filedetails/?id=1")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("ScrapeWorkshopPage() of a missing page: error = %v, want the 404 status", err)
+	}
+}
+
+func TestParseWorkshopHTMLWithoutAppID(t *testing.T) {
+	html := "<html><head><title>Steam Workshop::Some Item</title></head></html>"
+
+	info, err := ParseWorkshopHTML(html, "https://steamcommunity.com/sharedfiles/filedetails/?id=42")
+	if !errors.Is(err, ErrAppIDNotFound) {
+		t.Fatalf("ParseWorkshopHTML() error = %v, want ErrAppIDNotFound", err)
+	}
+	if info == nil || info.WorkshopID != "42" || info.Title != "Some Item" {
+		t.Errorf("partial info = %+v, want workshop ID and title", info)
+	}
+}
+
+func TestParseWorkshopHTMLRequiresWorkshopID(t *testing.T) {
+	if _, err := ParseWorkshopHTML("<html></html>", "https://steamcommunity.com/app/108600"); err == nil {
+		t.Fatal("ParseWorkshopHTML() expected an error for a URL without an id")
+	}
+}
+
+func TestParseWorkshopHTMLDecodesTitles(t *testing.T) {
+	tests := []struct {
+		name      string
+		title     string
+		game      string
+		wantTitle string
+		wantGame  string
+	}{
+		{"named entities", "Steam Workshop::Guns &amp; Ammo &quot;Pack&quot;", "Arma &amp; Co", `Guns & Ammo "Pack"`, "Arma & Co"},
+		{"numeric entities", "Steam Workshop::Caf&#233; &#x2014; Deluxe", "Caf&#233; Simulator", "Café — Deluxe", "Café Simulator"},
+		{"multibyte", "Steam Workshop::日本語マップ Ñandú", "ゲーム", "日本語マップ Ñandú", "ゲーム"},
+		{"byte order mark", "\uFEFFSteam Workshop::Clean\uFEFF Title", "\uFEFFClean Game", "Clean Title", "Clean Game"},
+		{"whitespace", "Steam Workshop::\n  Spread \t over\n lines  ", "\n  Some\tGame ", "Spread over lines", "Some Game"},
+		{"invalid utf-8", "Steam Workshop::Bad \xff byte", "Bad \xff game", "Bad � byte", "Bad � game"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := "<html><head><title>" + tt.title + "</title></head><body>" +
+				`<div class="apphub_AppName ellipsis">` + tt.game + "</div>appid=108600</body></html>"
+
+			info, err := ParseWorkshopHTML(html, "https://steamcommunity.com/sharedfiles/filedetails/?id=42")
+			if err != nil {
+				t.Fatalf("ParseWorkshopHTML() unexpected error = %v", err)
+			}
+			if info.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", info.Title, tt.wantTitle)
+			}
+			if info.GameName != tt.wantGame {
+				t.Errorf("GameName = %q, want %q", info.GameName, tt.wantGame)
+			}
+		})
+	}
+}
+
+func TestParseWorkshopHTMLGameName(t *testing.T) {
+	tests := map[string]string{
+		"breadcrumbs": `<div class="breadcrumbs"><a href="https://steamcommunity.com/app/108600">Project Zomboid</a> &gt; <a href="https://steamcommunity.com/app/108600/workshop/">Workshop</a></div>`,
+		"h1 header":   `<h1 class="apphub_AppName">Project Zomboid</h1>`,
+		"title only":  "",
+	}
+
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			html := "<html><head><title>Steam Workshop::Better Sorting</title></head><body>" + body + "appid=108600</body></html>"
+
+			info, err := ParseWorkshopHTML(html, "https://steamcommunity.com/sharedfiles/filedetails/?id=42")
+			if err != nil {
+				t.Fatalf("ParseWorkshopHTML() unexpected error = %v", err)
+			}
+			want := "Project Zomboid"
+			if body == "" {
+				want = "" // The title names the item, never the game
+			}
+			if info.GameName != want {
+				t.Errorf("GameName = %q, want %q", info.GameName, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package steamcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// InstalledItem is the version information SteamCMD records for a workshop item
+// in steamapps/workshop/appworkshop_<appid>.acf
+type InstalledItem struct {
+	ManifestID  string // Content manifest of the installed version
+	TimeUpdated string // Unix time the installed version was published
+	SizeBytes   string
+}
+
+// GetInstalledItem reads the recorded version of an installed workshop item
+func (c *Client) GetInstalledItem(appID, workshopID string) (*InstalledItem, error) {
+	state, path, err := c.readWorkshopState(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	installed := state.child("WorkshopItemsInstalled").child(workshopID)
+	if installed == nil {
+		return nil, fmt.Errorf("item %s is not recorded in %s", workshopID, path)
+	}
+
+	return &InstalledItem{
+		ManifestID:  installed.value("manifest"),
+		TimeUpdated: installed.value("timeupdated"),
+		SizeBytes:   installed.value("size"),
+	}, nil
+}
+
+// readWorkshopState parses the AppWorkshop section of an app's
+// appworkshop_<appid>.acf and returns it with the file's path
+func (c *Client) readWorkshopState(appID string) (*vdfNode, string, error) {
+	return readWorkshopStateIn(c.WorkingDir, appID)
+}
+
+// readWorkshopStateIn reads the appworkshop_<appid>.acf of the Steam or
+// SteamCMD install or library at dir
+func readWorkshopStateIn(dir, appID string) (*vdfNode, string, error) {
+	path := filepath.Join(dir, "steamapps", "workshop", fmt.Sprintf("appworkshop_%s.acf", appID))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to read workshop state: %w", err)
+	}
+
+	root, err := parseVDF(string(data))
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return root.child("AppWorkshop"), path, nil
+}
+
+// vdfNode is a section of Valve's KeyValues text format: quoted keys mapping
+// to either quoted strings or nested { } sections
+type vdfNode struct {
+	values   map[string]string
+	children map[string]*vdfNode
+}
+
+func newVDFNode() *vdfNode {
+	return &vdfNode{values: make(map[string]string), children: make(map[string]*vdfNode)}
+}
+
+// child returns the named subsection, matching keys case-insensitively like Steam does
+func (n *vdfNode) child(key string) *vdfNode {
+	if n == nil {
+		return nil
+	}
+	for k, v := range n.children {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// value returns the named string value, matching keys case-insensitively
+func (n *vdfNode) value(key string) string {
+	if n == nil {
+		return ""
+	}
+	for k, v := range n.values {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseVDF parses the KeyValues text used by .acf and .vdf files
+func parseVDF(data string) (*vdfNode, error) {
+	tokens, err := tokenizeVDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	root := newVDFNode()
+	stack := []*vdfNode{root}
+	for i := 0; i < len(tokens); i++ {
+		current := stack[len(stack)-1]
+
+		switch tokens[i] {
+		case "}":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unexpected '}'")
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		case "{":
+			return nil, fmt.Errorf("section without a name")
+		}
+
+		key := tokens[i]
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("key %q has no value", key)
+		}
+		i++
+
+		switch tokens[i] {
+		case "{":
+			node := newVDFNode()
+			current.children[key] = node
+			stack = append(stack, node)
+		case "}":
+			return nil, fmt.Errorf("key %q has no value", key)
+		default:
+			current.values[key] = tokens[i]
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("unterminated section")
+	}
+	return root, nil
+}
+
+// tokenizeVDF splits KeyValues text into quoted strings, bare words and braces,
+// dropping // comments. Quoted braces are returned without their quotes, so a
+// key or value of "{" would be mistaken for a brace; SteamCMD never writes one.
+func tokenizeVDF(data string) ([]string, error) {
+	var tokens []string
+	runes := []rune(data)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '{' || r == '}':
+			tokens = append(tokens, string(r))
+		case r == '"':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					continue
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, sb.String())
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '{' && runes[i] != '}' && runes[i] != '"' {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+			i--
+		}
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,111 @@
+package steamcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// appManifestRegex matches the appmanifest_<appid>.acf files Steam keeps in
+// each library's steamapps directory
+var appManifestRegex = regexp.MustCompile(`^appmanifest_(\d+)\.acf$`)
+
+// AppManifest is the part of a game's appmanifest_<appid>.acf used to
+// recognize its install directory
+type AppManifest struct {
+	AppID      string
+	Name       string
+	InstallDir string // Directory name under steamapps/common
+	Path       string // The .acf file itself
+}
+
+// FindAppManifest finds the app manifest of the Steam game installed at dir,
+// or at one of its parents, e.g. <library>/steamapps/common/<game>/mods.
+// A directory holding a single appmanifest_<appid>.acf is taken as that game.
+// It returns nil without an error when dir isn't inside a game install.
+func FindAppManifest(dir string) (*AppManifest, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The directory itself, or a copy of the game that kept its manifest
+	manifests, err := readAppManifests(abs)
+	if err != nil {
+		return nil, err
+	}
+	switch len(manifests) {
+	case 0:
+	case 1:
+		return manifests[0], nil
+	default:
+		return nil, fmt.Errorf("%s holds several app manifests; point at the game's directory under common", abs)
+	}
+
+	// A game install inside a Steam library
+	for current := abs; ; current = filepath.Dir(current) {
+		common := filepath.Dir(current)
+		steamapps := filepath.Dir(common)
+		if strings.EqualFold(filepath.Base(common), "common") && strings.EqualFold(filepath.Base(steamapps), "steamapps") {
+			manifests, err := readAppManifests(steamapps)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range manifests {
+				if strings.EqualFold(m.InstallDir, filepath.Base(current)) {
+					return m, nil
+				}
+			}
+			return nil, fmt.Errorf("no app manifest in %s has installdir %q", steamapps, filepath.Base(current))
+		}
+
+		if filepath.Dir(current) == current {
+			return nil, nil
+		}
+	}
+}
+
+// readAppManifests parses the app manifests directly inside dir
+func readAppManifests(dir string) ([]*AppManifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var manifests []*AppManifest
+	for _, entry := range entries {
+		match := appManifestRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read app manifest: %w", err)
+		}
+		root, err := parseVDF(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		state := root.child("AppState")
+		appID := state.value("appid")
+		if appID == "" {
+			appID = match[1]
+		}
+		manifests = append(manifests, &AppManifest{
+			AppID:      appID,
+			Name:       state.value("name"),
+			InstallDir: state.value("installdir"),
+			Path:       path,
+		})
+	}
+
+	return manifests, nil
+}
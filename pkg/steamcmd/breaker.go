@@ -0,0 +1,99 @@
+package steamcmd
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Breaker is a circuit breaker shared by every download of a run. When too many
+// SteamCMD attempts fail within Window it opens and holds back new attempts for
+// Cooldown, so an outage isn't made worse by every item retrying on its own.
+type Breaker struct {
+	Threshold int           // Failed attempts within Window that open the circuit
+	Window    time.Duration // How far back failures are counted
+	Cooldown  time.Duration // How long the circuit stays open
+
+	// OnOpen, if set, is called when the circuit opens with the number of
+	// recent failures and how long attempts are paused
+	OnOpen func(failures int, pause time.Duration)
+
+	mu        sync.Mutex
+	failures  []time.Time
+	openUntil time.Time
+	now       func() time.Time
+}
+
+// NewBreaker creates a breaker that opens after threshold failed attempts within window
+func NewBreaker(threshold int, window, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Window: window, Cooldown: cooldown}
+}
+
+func (b *Breaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// pause returns how long attempts must still wait for the circuit to close
+func (b *Breaker) pause() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return max(b.openUntil.Sub(b.clock()), 0)
+}
+
+// Wait blocks while the circuit is open. A nil Breaker never blocks.
+func (b *Breaker) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	pause := b.pause()
+	if pause == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Record counts the outcome of an attempt. Only failures that IsOutage
+// accepts count; an item that is private or gone says nothing about the
+// service.
+func (b *Breaker) Record(err error) {
+	if b == nil || b.Threshold <= 0 || !IsOutage(classifyError(err)) {
+		return
+	}
+
+	b.mu.Lock()
+	now := b.clock()
+
+	// Forget failures that fell out of the window
+	recent := b.failures[:0]
+	for _, t := range b.failures {
+		if now.Sub(t) < b.Window {
+			recent = append(recent, t)
+		}
+	}
+	b.failures = append(recent, now)
+
+	opened := len(b.failures) >= b.Threshold && !now.Before(b.openUntil)
+	failures := len(b.failures)
+	if opened {
+		b.openUntil = now.Add(b.Cooldown)
+		b.failures = nil
+	}
+	b.mu.Unlock()
+
+	if opened && b.OnOpen != nil {
+		b.OnOpen(failures, b.Cooldown)
+	}
+}
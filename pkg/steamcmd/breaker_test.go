@@ -0,0 +1,54 @@
+package steamcmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := NewBreaker(3, time.Minute, 2*time.Minute)
+	b.now = func() time.Time { return now }
+
+	var opened int
+	b.OnOpen = func(failures int, pause time.Duration) { opened++ }
+
+	timeout := errors.New("Download failed: Timeout")
+
+	b.Record(timeout)
+	b.Record(errors.New("Download failed: Access Denied")) // Says nothing about Steam
+	now = now.Add(70 * time.Second)
+	b.Record(timeout) // The first failure has left the window
+	b.Record(timeout)
+	if opened != 0 || b.pause() != 0 {
+		t.Fatalf("breaker opened after 2 failures within the window")
+	}
+
+	b.Record(timeout)
+	if opened != 1 {
+		t.Fatalf("OnOpen called %d times, want 1", opened)
+	}
+	if got := b.pause(); got != 2*time.Minute {
+		t.Errorf("pause() = %v, want 2m", got)
+	}
+
+	now = now.Add(90 * time.Second)
+	if got := b.pause(); got != 30*time.Second {
+		t.Errorf("pause() = %v, want 30s", got)
+	}
+
+	now = now.Add(30 * time.Second)
+	if got := b.pause(); got != 0 {
+		t.Errorf("pause() after cooldown = %v, want 0", got)
+	}
+}
+
+func TestNilBreaker(t *testing.T) {
+	var b *Breaker
+	b.Record(errors.New("timeout"))
+	if err := b.Wait(context.Background()); err != nil {
+		t.Errorf("Wait() on nil breaker = %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package steamcmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	depotSuccessRegex = regexp.MustCompile(`Depot download complete : "([^"]+)"`)
+	depotFailureRegex = regexp.MustCompile(`ERROR! Download depot \d+ failed \(([^)]+)\)`)
+)
+
+// legacyDepotPatterns are download failure reasons seen for old workshop items
+// that are distributed as depots instead of through workshop_download_item
+var legacyDepotPatterns = []string{
+	"file not found",
+	"no content",
+}
+
+// IsLegacyDepotFailure reports whether a download error looks like an old item
+// that has to be fetched with download_depot instead
+func IsLegacyDepotFailure(errorMsg string) bool {
+	errorLower := strings.ToLower(errorMsg)
+	for _, pattern := range legacyDepotPatterns {
+		if strings.Contains(errorLower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadDepot downloads a depot of an app with download_depot. This is the
+// fallback for legacy workshop items that aren't served by workshop_download_item.
+// An empty username logs in anonymously.
+func (c *Client) DownloadDepot(appID, depotID, username string) (*WorkshopItem, error) {
+	item := &WorkshopItem{
+		AppID: appID,
+	}
+
+	login := username
+	if login == "" {
+		login = "anonymous"
+	}
+
+	args := []string{
+		"+@ShutdownOnFailedCommand", "1", // Exit on command failure
+		"+@NoPromptForPassword", "1", // Fail instead of waiting for a password without cached credentials
+		"+login", login,
+		"+download_depot", appID, depotID,
+		"+quit",
+	}
+
+	parser := newOutputParser(item)
+	output, err := c.runStreaming(context.Background(), args, parser)
+	if username != "" && noCachedCredentials(output) {
+		return item, classifyError(fmt.Errorf("not logged on to Steam: no cached credentials for %s", username))
+	}
+	if err != nil {
+		return item, classifyError(fmt.Errorf("failed to run SteamCMD: %w\nOutput: %s", err, output))
+	}
+
+	if err := parser.result(); err != nil {
+		return item, classifyError(fmt.Errorf("failed to parse SteamCMD output: %w", err))
+	}
+
+	if !item.Success {
+		return item, classifyError(fmt.Errorf("depot download failed: %s", item.ErrorMsg))
+	}
+
+	return item, nil
+}
@@ -0,0 +1,172 @@
+package steamcmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/backoff"
+)
+
+// Errors classifying why a download failed. Errors returned by the download
+// methods wrap one of them, so callers can branch with errors.Is.
+var (
+	ErrNotLoggedOn      = errors.New("not logged on to Steam")
+	ErrLoginFailed      = errors.New("steam login failed")
+	ErrAccessDenied     = errors.New("access denied")
+	ErrNotOwned         = errors.New("app not owned by the account")
+	ErrItemNotFound     = errors.New("item not found")
+	ErrTimeout          = errors.New("timed out")
+	ErrRateLimited      = errors.New("rate limited by Steam")
+	ErrNetwork          = errors.New("network error")
+	ErrSteamCMDFailed   = errors.New("steamcmd could not be run")
+	ErrUnexpectedOutput = errors.New("unexpected steamcmd output")
+	ErrDownloadFailed   = errors.New("download failed")
+	ErrEmptyDownload    = errors.New("download is empty")
+)
+
+// errorClasses maps message fragments to their classification, most specific first
+var errorClasses = []struct {
+	patterns []string
+	kind     error
+}{
+	{[]string{"unhandled steamcmd output", "without reporting a result", "unknown error"}, ErrUnexpectedOutput},
+	{[]string{"rate limit", "throttle", "too many", "limit exceeded"}, ErrRateLimited},
+	{[]string{"not logged on"}, ErrNotLoggedOn},
+	{[]string{"login failed", "invalid password", "credentials", "two-factor"}, ErrLoginFailed},
+	{[]string{"no subscription", "no license", "missing license"}, ErrNotOwned},
+	{[]string{"access denied"}, ErrAccessDenied},
+	{[]string{"not found", "no content"}, ErrItemNotFound},
+	{[]string{"timeout", "timed out"}, ErrTimeout},
+	{[]string{"connection", "network", "no route", "unreachable"}, ErrNetwork},
+	{[]string{"failed to run steamcmd"}, ErrSteamCMDFailed},
+}
+
+// unretryableKinds are the classifications of failures that retrying cannot fix
+var unretryableKinds = []error{
+	ErrUnexpectedOutput,
+	ErrLoginFailed,
+	ErrNotOwned,
+	ErrAccessDenied,
+	ErrItemNotFound,
+}
+
+// outageKinds are the classifications of failures a Steam outage causes:
+// network problems, timeouts, rate limits and Steam's generic "Failure"
+var outageKinds = []error{ErrNetwork, ErrTimeout, ErrRateLimited, ErrDownloadFailed}
+
+// DownloadError is a failed download together with its classification
+type DownloadError struct {
+	Kind error // One of the Err* classification errors
+	Err  error // The underlying error
+}
+
+func (e *DownloadError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap exposes both the classification and the underlying error to errors.Is/As
+func (e *DownloadError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// RetryError is a download that failed after several attempts. It reads as
+// the last attempt's error prefixed with how often each reason came up.
+type RetryError struct {
+	Attempts []*DownloadError // Every failed attempt, oldest first
+}
+
+// Reason is one distinct cause of failed attempts
+type Reason struct {
+	Kind  error
+	Count int
+}
+
+// Reasons counts the attempts of each classification, in order of first occurrence
+func (e *RetryError) Reasons() []Reason {
+	var reasons []Reason
+	index := make(map[error]int)
+	for _, attempt := range e.Attempts {
+		i, ok := index[attempt.Kind]
+		if !ok {
+			i = len(reasons)
+			index[attempt.Kind] = i
+			reasons = append(reasons, Reason{Kind: attempt.Kind})
+		}
+		reasons[i].Count++
+	}
+	return reasons
+}
+
+func (e *RetryError) Error() string {
+	var parts []string
+	for _, r := range e.Reasons() {
+		parts = append(parts, fmt.Sprintf("%v x%d", r.Kind, r.Count))
+	}
+	return fmt.Sprintf("after %d attempts (%s): %v", len(e.Attempts), strings.Join(parts, ", "), e.Unwrap())
+}
+
+// Unwrap returns the last attempt's error, so the classification of the
+// download is that of its final failure
+func (e *RetryError) Unwrap() error {
+	return e.Attempts[len(e.Attempts)-1]
+}
+
+// classifyError wraps err in a DownloadError carrying its classification.
+// Errors that already carry one are returned as they are.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *DownloadError
+	if errors.As(err, &classified) {
+		return err
+	}
+	return asDownloadError(err)
+}
+
+// asDownloadError returns the DownloadError err carries, or classifies it
+func asDownloadError(err error) *DownloadError {
+	var classified *DownloadError
+	if errors.As(err, &classified) {
+		return classified
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, class := range errorClasses {
+		for _, pattern := range class.patterns {
+			if strings.Contains(msg, pattern) {
+				return &DownloadError{Kind: class.kind, Err: err}
+			}
+		}
+	}
+
+	return &DownloadError{Kind: ErrDownloadFailed, Err: err}
+}
+
+// IsOutage reports whether err is a classified failure of one of the
+// outageKinds. A failure that only concerns the item or the account, such as
+// access denied or an empty download, says nothing about whether Steam is
+// down, and neither does an error that never reached SteamCMD.
+func IsOutage(err error) bool {
+	var classified *DownloadError
+	if !errors.As(err, &classified) {
+		return false
+	}
+	return slices.Contains(outageKinds, classified.Kind)
+}
+
+// retryCategory picks the backoff schedule for retrying after a failure of
+// the given kind. Anything but a rate limit or a network problem is retried
+// on the server schedule.
+func retryCategory(kind error) backoff.Category {
+	switch kind {
+	case ErrRateLimited:
+		return backoff.CategoryRateLimit
+	case ErrNetwork, ErrTimeout:
+		return backoff.CategoryNetwork
+	}
+	return backoff.CategoryServer
+}
@@ -0,0 +1,41 @@
+package steamcmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// GuardCodeSource fetches the Steam Guard code Steam sent for a login, e.g.
+// from the account's mailbox. See the steamguard package.
+type GuardCodeSource interface {
+	// GuardCode returns the code of the first Steam Guard message received
+	// after since, waiting for it to arrive until ctx is done
+	GuardCode(ctx context.Context, since time.Time) (string, error)
+}
+
+// guardCode gets the Steam Guard code for a login started at since, from
+// GuardCodes when set and by prompting on stdin otherwise or when it fails
+func (c *Client) guardCode(since time.Time) (string, error) {
+	if c.GuardCodes != nil {
+		fmt.Fprintln(c.out(), "Fetching the Steam Guard code from the mailbox...")
+		code, err := c.GuardCodes.GuardCode(context.Background(), since)
+		if err == nil {
+			return code, nil
+		}
+		fmt.Fprintf(c.out(), "Could not fetch the Steam Guard code: %v\n", err)
+	}
+
+	fmt.Fprintln(c.out(), "Please check your email for the Steam Guard code.")
+	fmt.Fprint(c.out(), "Enter Steam Guard code: ")
+
+	reader := bufio.NewReader(os.Stdin)
+	code, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read Steam Guard code: %w", err)
+	}
+	return strings.TrimSpace(code), nil
+}
@@ -0,0 +1,124 @@
+package steamcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DownloadedItem is a workshop item found in SteamCMD's workshop content directory
+type DownloadedItem struct {
+	AppID      string
+	WorkshopID string
+	Path       string
+	SizeBytes  int64
+
+	// From appworkshop_<appid>.acf when SteamCMD recorded the item there
+	ManifestID     string
+	TimeUpdated    time.Time // When the installed version was published
+	LastDownloaded time.Time // Falls back to the directory's modification time
+
+	// Title isn't stored locally; callers may fill it in from the Web API
+	Title string
+}
+
+// ListItems lists the downloaded workshop items sorted by App ID, then
+// workshop ID, with their size and whatever SteamCMD recorded about them
+func (c *Client) ListItems() ([]DownloadedItem, error) {
+	downloaded, err := c.ListDownloadedItems()
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]string, 0, len(downloaded))
+	for appID := range downloaded {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Strings(appIDs)
+
+	var items []DownloadedItem
+	for _, appID := range appIDs {
+		// Missing or unreadable state only costs the recorded metadata
+		state, _, _ := c.readWorkshopState(appID)
+
+		workshopIDs := downloaded[appID]
+		sort.Strings(workshopIDs)
+		for _, workshopID := range workshopIDs {
+			item := DownloadedItem{
+				AppID:      appID,
+				WorkshopID: workshopID,
+				Path:       filepath.Join(c.GetWorkshopPath(), appID, workshopID),
+			}
+			item.SizeBytes = dirSize(item.Path)
+
+			installed := state.child("WorkshopItemsInstalled").child(workshopID)
+			item.ManifestID = installed.value("manifest")
+			item.TimeUpdated = unixTime(installed.value("timeupdated"))
+			item.LastDownloaded = unixTime(state.child("WorkshopItemDetails").child(workshopID).value("timetouched"))
+			if item.LastDownloaded.IsZero() {
+				if info, err := os.Stat(item.Path); err == nil {
+					item.LastDownloaded = info.ModTime().UTC().Truncate(time.Second)
+				}
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// unixTime parses a Unix timestamp as written in .acf files; empty or zero
+// values give the zero time
+func unixTime(s string) time.Time {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// dirSize returns the total size of the files under path
+func dirSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Continue on errors
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// EmptyContent returns why the content directory of a successful download
+// doesn't hold any content, or "" if it does
+func EmptyContent(path string) string {
+	if path == "" {
+		return "SteamCMD reported no content directory"
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Sprintf("content directory %s does not exist", path)
+	}
+
+	var files int
+	filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files++
+		}
+		return nil
+	})
+
+	switch {
+	case files == 0:
+		return fmt.Sprintf("no files in %s", path)
+	case dirSize(path) == 0:
+		return fmt.Sprintf("all %d file(s) in %s are 0 bytes", files, path)
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package steamcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// SystemSteamDirs returns the install directories of the Steam client found
+// in the usual places on this machine
+func SystemSteamDirs() []string {
+	var candidates []string
+
+	homeDir, err := os.UserHomeDir()
+	if err == nil {
+		switch runtime.GOOS {
+		case "darwin":
+			candidates = append(candidates, filepath.Join(homeDir, "Library", "Application Support", "Steam"))
+		case "windows":
+			for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+				if dir := os.Getenv(env); dir != "" {
+					candidates = append(candidates, filepath.Join(dir, "Steam"))
+				}
+			}
+			candidates = append(candidates, filepath.Join(homeDir, "AppData", "Local", "Steam"))
+		case "linux":
+			candidates = append(candidates,
+				filepath.Join(homeDir, ".steam", "steam"),
+				filepath.Join(homeDir, ".local", "share", "Steam"),
+				filepath.Join(homeDir, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"), // Flatpak
+			)
+		}
+	}
+
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, dir := range candidates {
+		// ~/.steam/steam is usually a link to ~/.local/share/Steam
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(resolved, "steamapps")); err == nil {
+			seen[resolved] = true
+			dirs = append(dirs, resolved)
+		}
+	}
+	return dirs
+}
+
+// SteamLibraries returns the library folders of the Steam client installs at
+// steamDirs, as listed in their steamapps/libraryfolders.vdf, each install's
+// own directory first
+func SteamLibraries(steamDirs []string) ([]string, error) {
+	var libraries []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			libraries = append(libraries, dir)
+		}
+	}
+
+	for _, steamDir := range steamDirs {
+		add(steamDir)
+
+		path := filepath.Join(steamDir, "steamapps", "libraryfolders.vdf")
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Steam libraries: %w", err)
+		}
+		root, err := parseVDF(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		folders := root.child("libraryfolders")
+		if folders == nil {
+			continue
+		}
+		// Current format: numbered sections with a path; older clients
+		// wrote the paths as numbered values
+		for _, folder := range folders.children {
+			if p := folder.value("path"); p != "" {
+				add(p)
+			}
+		}
+		for key, value := range folders.values {
+			if isDigits(key) {
+				add(value)
+			}
+		}
+	}
+
+	return libraries, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// GameLibrary returns the library among libraries the game appID is installed
+// in, recognized by its appmanifest_<appid>.acf
+func GameLibrary(libraries []string, appID string) (string, error) {
+	for _, library := range libraries {
+		manifest := filepath.Join(library, "steamapps", fmt.Sprintf("appmanifest_%s.acf", appID))
+		if _, err := os.Stat(manifest); err == nil {
+			return library, nil
+		}
+	}
+	return "", fmt.Errorf("app %s is not installed in any Steam library (searched %d)", appID, len(libraries))
+}
+
+// GameWorkshopDir returns the directory the Steam client loads the workshop
+// content of appID from in library
+func GameWorkshopDir(library, appID string) string {
+	return filepath.Join(library, "steamapps", "workshop", "content", appID)
+}
+
+// ClientManagesItem reports whether the Steam client tracks workshopID in
+// library, i.e. the account is subscribed to it and Steam may update or
+// remove its files at any time
+func ClientManagesItem(library, appID, workshopID string) (bool, error) {
+	state, _, err := readWorkshopStateIn(library, appID)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return state.child("WorkshopItemsInstalled").child(workshopID) != nil ||
+		state.child("WorkshopItemDetails").child(workshopID) != nil, nil
+}
@@ -0,0 +1,117 @@
+package steamcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockFileName is created in the SteamCMD directory while a command uses it
+const LockFileName = "workshop.lock"
+
+// lockPollInterval is how often a waiting AcquireLock retries
+const lockPollInterval = 500 * time.Millisecond
+
+// ErrLocked is returned when another process holds the SteamCMD directory lock
+var ErrLocked = errors.New("another workshop operation is in progress")
+
+// errLockHeld is returned by lockFile when another process holds the lock
+var errLockHeld = errors.New("lock held by another process")
+
+// errLockMoved is returned by tryLock when the lock file was released and
+// removed while it was being locked
+var errLockMoved = errors.New("lock file replaced")
+
+// Lock guards a SteamCMD directory against concurrent runs, which would
+// corrupt each other's workshop cache and logs
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// AcquireLock takes the lock of the SteamCMD directory dir, waiting up to wait
+// for another process to release it.
+//
+// The lock is an OS file lock on the lock file (flock, or LockFileEx on
+// Windows), which the OS drops when its process dies. A lock file left
+// behind by a crashed run is simply locked again, so no two processes can
+// both decide to take it over.
+func AcquireLock(dir string, wait time.Duration) (*Lock, error) {
+	path := filepath.Join(dir, LockFileName)
+	deadline := time.Now().Add(wait)
+
+	for {
+		lock, err := tryLock(path)
+		if err == nil {
+			return lock, nil
+		}
+		if errors.Is(err, errLockMoved) {
+			continue
+		}
+		if !errors.Is(err, errLockHeld) {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			if pid := readLockOwner(path); pid > 0 {
+				return nil, fmt.Errorf("%w (pid %d holds %s)", ErrLocked, pid, path)
+			}
+			return nil, fmt.Errorf("%w (%s is locked)", ErrLocked, path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// tryLock locks the lock file at path without waiting, recording our PID in it
+func tryLock(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if errors.Is(err, errLockHeld) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// The previous holder removes the file on release, possibly after we
+	// opened it; a lock on a removed file guards nothing
+	opened, err := f.Stat()
+	current, statErr := os.Stat(path)
+	if err != nil || statErr != nil || !os.SameFile(opened, current) {
+		unlockFile(f)
+		f.Close()
+		return nil, errLockMoved
+	}
+
+	// The PID is only informational, for the error other processes report
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{path: path, file: f}, nil
+}
+
+// readLockOwner returns the PID recorded in the lock file, or 0 when there's
+// none, e.g. because it's being written right now
+func readLockOwner(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// Release removes the lock file and drops the lock
+func (l *Lock) Release() error {
+	return releaseFile(l.path, l.file)
+}
@@ -0,0 +1,95 @@
+package steamcmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireLockTakesOverStaleLock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, LockFileName)
+
+	// Left behind by a crashed run: a PID, but nobody holding the lock
+	if err := os.WriteFile(path, []byte("999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only one of several processes taking it over at once may win
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		won     []*Lock
+		lockErr []error
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := AcquireLock(dir, 0)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				lockErr = append(lockErr, err)
+				return
+			}
+			won = append(won, lock)
+		}()
+	}
+	wg.Wait()
+
+	if len(won) != 1 {
+		t.Fatalf("%d takeovers of the stale lock succeeded, want 1", len(won))
+	}
+	for _, err := range lockErr {
+		if !errors.Is(err, ErrLocked) {
+			t.Errorf("AcquireLock() error = %v, want ErrLocked", err)
+		}
+	}
+	if data, _ := os.ReadFile(path); strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lock file = %q, want our PID", data)
+	}
+
+	if err := won[0].Release(); err != nil {
+		t.Fatalf("Release() unexpected error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file still exists after Release: %v", err)
+	}
+}
+
+func TestAcquireLockTimeout(t *testing.T) {
+	dir := t.TempDir()
+	held, err := AcquireLock(dir, 0)
+	if err != nil {
+		t.Fatalf("AcquireLock() unexpected error = %v", err)
+	}
+
+	start := time.Now()
+	_, err = AcquireLock(dir, 2*lockPollInterval)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("AcquireLock() on a held lock error = %v, want ErrLocked", err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*lockPollInterval {
+		t.Errorf("AcquireLock() gave up after %v, want at least %v", elapsed, 2*lockPollInterval)
+	}
+	if !strings.Contains(err.Error(), "pid "+strconv.Itoa(os.Getpid())) {
+		t.Errorf("AcquireLock() error = %q, want the holder's PID", err)
+	}
+
+	// Waiting long enough gets the lock once it's released
+	go func() {
+		time.Sleep(lockPollInterval)
+		held.Release()
+	}()
+	lock, err := AcquireLock(dir, 4*lockPollInterval)
+	if err != nil {
+		t.Fatalf("AcquireLock() after Release unexpected error = %v", err)
+	}
+	lock.Release()
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package steamcmd
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile takes an exclusive flock on f, failing with errLockHeld instead of
+// waiting when another process has it
+func lockFile(f *os.File) error {
+	err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return errLockHeld
+	}
+	return err
+}
+
+// unlockFile drops the lock lockFile took
+func unlockFile(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_UN)
+}
+
+// releaseFile removes the lock file before dropping the lock, so the next
+// process can't lock a file that is about to disappear
+func releaseFile(path string, f *os.File) error {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	unlockFile(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package steamcmd
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockRegion is the byte range locked: one byte far past the PID, since
+// Windows locks are mandatory and would keep others from reading it
+var lockRegion = windows.Overlapped{OffsetHigh: 1}
+
+// lockFile takes an exclusive LockFileEx lock on f, failing with errLockHeld
+// instead of waiting when another process has it
+func lockFile(f *os.File) error {
+	ol := lockRegion
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLockHeld
+	}
+	return err
+}
+
+// unlockFile drops the lock lockFile took
+func unlockFile(f *os.File) error {
+	ol := lockRegion
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &ol)
+}
+
+// releaseFile drops the lock, then removes the lock file. Windows can't
+// remove a file another process has open, so the removal fails harmlessly
+// when the next process already opened it to take the lock.
+func releaseFile(path string, f *os.File) error {
+	unlockFile(f)
+	err := f.Close()
+	os.Remove(path)
+	return err
+}
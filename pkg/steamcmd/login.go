@@ -0,0 +1,122 @@
+package steamcmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CachedLogin is an account SteamCMD remembers in config/loginusers.vdf
+type CachedLogin struct {
+	Username   string
+	SteamID    string
+	LastLogin  time.Time // Zero when SteamCMD didn't record it
+	MostRecent bool      // The account SteamCMD logged in with last
+}
+
+// CachedLogins lists the accounts SteamCMD remembers, most recent login first
+func (c *Client) CachedLogins() ([]CachedLogin, error) {
+	path := filepath.Join(c.WorkingDir, "config", "loginusers.vdf")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached logins: %w", err)
+	}
+
+	root, err := parseVDF(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var logins []CachedLogin
+	if users := root.child("users"); users != nil {
+		for steamID, user := range users.children {
+			logins = append(logins, CachedLogin{
+				Username:   user.value("AccountName"),
+				SteamID:    steamID,
+				LastLogin:  unixTime(user.value("Timestamp")),
+				MostRecent: user.value("MostRecent") == "1",
+			})
+		}
+	}
+
+	sort.Slice(logins, func(i, j int) bool {
+		if logins[i].MostRecent != logins[j].MostRecent {
+			return logins[i].MostRecent
+		}
+		return logins[i].LastLogin.After(logins[j].LastLogin)
+	})
+	return logins, nil
+}
+
+// CheckCachedLogin logs in as username with SteamCMD's cached credentials,
+// without prompting for a password, to tell whether downloads as that user
+// will work. It returns an error wrapping ErrNotLoggedOn when there are no
+// usable cached credentials.
+func (c *Client) CheckCachedLogin(username string) error {
+	args := []string{
+		"+@ShutdownOnFailedCommand", "1",
+		"+@NoPromptForPassword", "1", // Fail instead of asking for the password
+		"+login", username,
+		"+quit",
+	}
+
+	ctx, cancel := c.loginContext()
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.SteamCMDPath, append(c.platformArgs(), args...)...)
+	cmd.Dir = c.WorkingDir
+
+	var outputBuf bytes.Buffer
+	cmd.Stdout = &outputBuf
+	cmd.Stderr = &outputBuf
+
+	err := cmd.Run()
+	output := outputBuf.String()
+	if ctx.Err() == context.DeadlineExceeded {
+		return &PhaseTimeoutError{Phase: PhaseLogin, After: c.LoginTimeout}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if loggedInRegex.MatchString(strings.TrimSpace(line)) {
+			return nil
+		}
+	}
+
+	lower := strings.ToLower(output)
+	switch {
+	case strings.Contains(lower, "no connection"):
+		return fmt.Errorf("no internet connection or Steam servers unreachable")
+	case noCachedCredentials(output),
+		strings.Contains(lower, "password"),
+		strings.Contains(lower, "steam guard"),
+		strings.Contains(lower, "two-factor"):
+		return &DownloadError{Kind: ErrNotLoggedOn, Err: fmt.Errorf("no valid cached credentials for %s", username)}
+	case strings.Contains(lower, "failed"), strings.Contains(lower, "logon denied"):
+		return &DownloadError{Kind: ErrLoginFailed, Err: fmt.Errorf("login as %s failed: %s", username, lastLine(output))}
+	}
+
+	if err != nil {
+		return &DownloadError{Kind: ErrSteamCMDFailed, Err: fmt.Errorf("SteamCMD login check failed: %w\nOutput: %s", err, output)}
+	}
+	return fmt.Errorf("login check inconclusive: %s", lastLine(output))
+}
+
+// noCachedCredentials reports whether SteamCMD failed a password-less login
+// because it has no cached credentials for the account
+func noCachedCredentials(output string) bool {
+	return strings.Contains(strings.ToLower(output), "cached credentials not found")
+}
+
+// lastLine returns the last non-empty line of output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,296 @@
+package steamcmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTailLines is how many trailing output lines are kept for error messages
+const maxTailLines = 100
+
+// maxUnhandledLines is how many non-banner lines are quoted for unhandled output
+const maxUnhandledLines = 20
+
+var (
+	successRegex         = regexp.MustCompile(`Success\. Downloaded item (\d+) to "([^"]+)" \((\d+) bytes\)`)
+	downloadFailureRegex = regexp.MustCompile(`ERROR! Download item (\d+) failed \(([^)]+)\)`)
+	loginFailureRegex    = regexp.MustCompile(`FAILED \(([^)]+)\)`)
+
+	// loginUserRegex and anonymousLoginRegex match the line naming the account
+	// SteamCMD logs in with, e.g. "Logging in user 'name' [U:1:123] to Steam Public...OK"
+	loginUserRegex      = regexp.MustCompile(`^Logging in user '([^']+)'`)
+	anonymousLoginRegex = regexp.MustCompile(`^Connecting anonymously to Steam Public`)
+
+	// notOwnedRegex matches the license failures SteamCMD reports outside the
+	// download result line, e.g. "ERROR! Failed to install app '4000' (No subscription)"
+	notOwnedRegex = regexp.MustCompile(`^ERROR! .*\((No subscription|No license|Missing license)\)`)
+)
+
+// benignPatterns match banner and housekeeping lines SteamCMD prints on every run.
+// They never carry a result, so they are left out when reporting unhandled output.
+var benignPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\s*$`),
+	regexp.MustCompile(`^Redirecting stderr to `),
+	regexp.MustCompile(`^Logging directory: `),
+	regexp.MustCompile(`^ILocalize::AddFile\(\) failed`),
+	regexp.MustCompile(`^\[\s*\d+%\] `), // self-update progress
+	regexp.MustCompile(`^\[----\] `),    // self-update steps
+	regexp.MustCompile(`^UpdateUI: `),
+	regexp.MustCompile(`^WARNING: setlocale`),
+	regexp.MustCompile(`^dlmopen steamservice\.so failed`),
+	regexp.MustCompile(`^/tmp/dumps is not owned by us`),
+	regexp.MustCompile(`^Steam Console Client \(c\) Valve Corporation`),
+	regexp.MustCompile(`^-- type 'quit' to exit --`),
+	regexp.MustCompile(`^Loading Steam API\.\.\.OK`),
+	regexp.MustCompile(`^Unloading Steam API\.\.\.OK`),
+	regexp.MustCompile(`^Connecting anonymously to Steam Public\.\.\.OK`),
+	regexp.MustCompile(`^Logging in user '[^']+' .*\.\.\.OK$`),
+	regexp.MustCompile(`^Waiting for (client config|user info|compat in post-logon)\.\.\.OK`),
+	regexp.MustCompile(`^Downloading item \d+ \.\.\.\s*$`),
+}
+
+// isBenignLine reports whether a line is a known banner with no result in it
+func isBenignLine(line string) bool {
+	for _, pattern := range benignPatterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputParser classifies SteamCMD output line by line as it is produced
+type outputParser struct {
+	item *WorkshopItem
+
+	success       []string // submatches of the success line
+	depotSuccess  []string // submatches of the depot download success line
+	downloadError []string // submatches of the download failure line
+	loginError    []string // submatches of the login failure line
+	notOwned      []string // submatches of a license failure line
+	account       string   // login named by the output, see WorkshopItem.Account
+	unrecognized  []string // first lines that are neither results nor known banners
+
+	onProgress func(Progress) // Called with each progress line, if set
+}
+
+func newOutputParser(item *WorkshopItem) *outputParser {
+	return &outputParser{item: item}
+}
+
+// feed processes one output line and reports whether it is fatal,
+// meaning there's no point in letting SteamCMD run any longer
+func (p *outputParser) feed(line string) bool {
+	// The login line may also carry a login failure, so keep going
+	if matches := loginUserRegex.FindStringSubmatch(line); matches != nil {
+		p.account = matches[1]
+	} else if anonymousLoginRegex.MatchString(line) {
+		p.account = AccountAnonymous
+	}
+
+	if progress, ok := parseProgress(line); ok {
+		if p.onProgress != nil {
+			p.onProgress(progress)
+		}
+		return false
+	}
+
+	if matches := successRegex.FindStringSubmatch(line); matches != nil && p.success == nil {
+		p.success = matches
+		return false
+	}
+
+	if matches := depotSuccessRegex.FindStringSubmatch(line); matches != nil && p.depotSuccess == nil {
+		p.depotSuccess = matches
+		return false
+	}
+
+	if matches := downloadFailureRegex.FindStringSubmatch(line); matches != nil && p.downloadError == nil {
+		p.downloadError = matches
+		return false
+	}
+
+	if matches := depotFailureRegex.FindStringSubmatch(line); matches != nil && p.downloadError == nil {
+		// Normalize to the workshop layout: reason in the third submatch
+		p.downloadError = []string{matches[0], "", matches[1]}
+		return false
+	}
+
+	if matches := notOwnedRegex.FindStringSubmatch(line); matches != nil && p.notOwned == nil {
+		p.notOwned = matches
+		return false
+	}
+
+	if matches := loginFailureRegex.FindStringSubmatch(line); matches != nil && p.loginError == nil {
+		p.loginError = matches
+		// Nothing useful can happen after a failed login
+		return true
+	}
+
+	if !isBenignLine(line) && len(p.unrecognized) < maxUnhandledLines {
+		p.unrecognized = append(p.unrecognized, line)
+	}
+
+	return false
+}
+
+// result fills the item from the lines seen so far. It returns an error
+// when no known success or failure line was seen.
+func (p *outputParser) result() error {
+	item := p.item
+	item.Account = p.account
+
+	// Check for success case
+	if matches := p.success; matches != nil {
+		item.Success = true
+		item.PathToFile = matches[2]
+
+		if bytes, err := strconv.ParseInt(matches[3], 10, 64); err == nil {
+			item.SizeBytes = bytes
+		}
+
+		return nil
+	}
+
+	// Check for depot download success (size isn't reported)
+	if matches := p.depotSuccess; matches != nil {
+		item.Success = true
+		item.PathToFile = matches[1]
+		return nil
+	}
+
+	// Check for download failure
+	if matches := p.downloadError; matches != nil {
+		item.Success = false
+		item.ErrorMsg = fmt.Sprintf("Download failed: %s", matches[2])
+		return nil
+	}
+
+	// Check for a license failure reported outside the result line
+	if matches := p.notOwned; matches != nil {
+		item.Success = false
+		item.ErrorMsg = fmt.Sprintf("Download failed: %s", matches[1])
+		return nil
+	}
+
+	// Check for login failure
+	if matches := p.loginError; matches != nil {
+		item.Success = false
+		item.ErrorMsg = fmt.Sprintf("Login failed: %s", matches[1])
+		return nil
+	}
+
+	// If we can't parse the output, it's an unknown error
+	item.Success = false
+	item.ErrorMsg = "Unknown error occurred"
+
+	if len(p.unrecognized) == 0 {
+		return fmt.Errorf("SteamCMD finished without reporting a result (only startup banners in output)")
+	}
+	return fmt.Errorf("unhandled SteamCMD output: %s", strings.Join(p.unrecognized, "\n"))
+}
+
+// ParsedOutput is how a download run classifies some SteamCMD output
+type ParsedOutput struct {
+	Item      *WorkshopItem
+	Err       error // The classified error the download fails with; nil on success
+	Retryable bool  // Whether the download retries after this output
+}
+
+// ParseOutput classifies saved SteamCMD output, e.g. a log a user sent in,
+// the way a download classifies live output, so parsing problems can be
+// reproduced without running SteamCMD. It only fails when r can't be read.
+func (c *Client) ParseOutput(r io.Reader) (*ParsedOutput, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, err
+	}
+
+	item := &WorkshopItem{}
+	parsed := &ParsedOutput{Item: item}
+	if err := c.parseOutput(&buf, item); err != nil {
+		parsed.Err = classifyError(fmt.Errorf("failed to parse SteamCMD output: %w", err))
+	} else if !item.Success {
+		parsed.Err = classifyError(fmt.Errorf("download failed: %s", item.ErrorMsg))
+	}
+	parsed.Retryable = !item.Success && c.isRetryableError(item.ErrorMsg)
+
+	return parsed, nil
+}
+
+// runStreaming runs SteamCMD and scans its combined output line by line while it
+// runs, feeding each line to the parser and to the OnOutput hook. The process is
+// stopped as soon as the parser sees a fatal line, or with a *PhaseTimeoutError
+// when it exceeds LoginTimeout or Timeout. It returns the last lines of output
+// for diagnostics instead of buffering everything.
+func (c *Client) runStreaming(ctx context.Context, args []string, parser *outputParser) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	watch := newPhaseWatch(c.LoginTimeout, c.Timeout, cancel)
+
+	cmd := exec.CommandContext(ctx, c.SteamCMDPath, append(c.platformArgs(), args...)...)
+	cmd.Dir = c.WorkingDir
+	// steamcmd.sh may leave a child holding the pipe after being killed
+	cmd.WaitDelay = 5 * time.Second
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		waitErr <- err
+	}()
+
+	var tail []string
+	stopped := false
+
+	scanner := bufio.NewScanner(pr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		tail = append(tail, line)
+		if len(tail) > maxTailLines {
+			tail = tail[1:]
+		}
+
+		if c.OnOutput != nil {
+			c.OnOutput(line)
+		}
+
+		watch.line(line)
+		if !stopped && parser.feed(line) {
+			stopped = true
+			cancel()
+		}
+	}
+	// Keep draining so SteamCMD never blocks on a full pipe
+	io.Copy(io.Discard, pr)
+
+	err := <-waitErr
+	if timeoutErr := watch.stop(); timeoutErr != nil {
+		return strings.Join(tail, "\n"), timeoutErr
+	}
+	if stopped {
+		// We killed the process on purpose; the parser has the reason
+		err = nil
+	}
+
+	return strings.Join(tail, "\n"), err
+}
@@ -0,0 +1,29 @@
+package steamcmd
+
+import "fmt"
+
+// Platforms SteamCMD can be told to download content for
+const (
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+)
+
+// ValidatePlatform checks a Client.Platform value; empty means the host's platform
+func ValidatePlatform(platform string) error {
+	switch platform {
+	case "", PlatformWindows, PlatformMacOS, PlatformLinux:
+		return nil
+	}
+	return fmt.Errorf("invalid platform %q: must be windows, macos or linux", platform)
+}
+
+// platformArgs returns the arguments making SteamCMD download content for
+// Platform instead of the host's platform. The setting has to come before
+// +login to take effect.
+func (c *Client) platformArgs() []string {
+	if c.Platform == "" {
+		return nil
+	}
+	return []string{"+@sSteamCmdForcePlatformType", c.Platform}
+}
@@ -0,0 +1,62 @@
+package steamcmd
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// progressRegex matches the download progress lines SteamCMD prints, e.g.
+// "Update state (0x61) downloading, progress: 60.02 (3145728 / 5241856)"
+var progressRegex = regexp.MustCompile(`progress: \d+(?:\.\d+)? \((\d+) / (\d+)\)`)
+
+// Progress is how much of a download SteamCMD reported having, in bytes
+type Progress struct {
+	Done  int64
+	Total int64
+}
+
+// Percent returns Done as a percentage of Total, or 0 when Total is unknown
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Done) * 100 / float64(p.Total)
+}
+
+// parseProgress reads a progress line, reporting false for any other line
+func parseProgress(line string) (Progress, bool) {
+	matches := progressRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return Progress{}, false
+	}
+	done, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return Progress{}, false
+	}
+	total, err := strconv.ParseInt(matches[2], 10, 64)
+	if err != nil || total <= 0 {
+		return Progress{}, false
+	}
+	return Progress{Done: done, Total: total}, true
+}
+
+// resumeTracker reports how much of an item a retry started from. SteamCMD
+// keeps the chunks a failed attempt fetched, so the first progress line of a
+// retry shows what was already cached.
+type resumeTracker struct {
+	previous Progress // Where the last attempt stopped
+}
+
+// watch hooks the tracker into the parser of an attempt, calling OnResume on
+// the first progress line of a retry
+func (t *resumeTracker) watch(c *Client, parser *outputParser, attempt int) {
+	previous := t.previous
+	reported := attempt <= 1 || c.OnResume == nil
+	parser.onProgress = func(p Progress) {
+		t.previous = p
+		if !reported {
+			reported = true
+			c.OnResume(attempt-1, p, previous)
+		}
+	}
+}
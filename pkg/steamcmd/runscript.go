@@ -0,0 +1,94 @@
+package steamcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// runscriptArgs writes the SteamCMD commands in args ("+login", "user",
+// "pass", "+quit", ...) to a script file and returns the arguments that run
+// it with +runscript instead. Commands in a script aren't visible to other
+// users through the process list, so passwords never appear on argv, and the
+// command line stays short however many commands there are.
+//
+// The file is created readable by the current user only (os.CreateTemp uses
+// mode 0600). cleanup blanks it before deleting it and must be called as
+// soon as SteamCMD has exited; RemoveRunscripts covers exits that never get
+// there.
+func (c *Client) runscriptArgs(args []string) (scriptArgs []string, cleanup func(), err error) {
+	script := runscript(args)
+
+	f, err := os.CreateTemp(c.WorkingDir, ".workshop-runscript-*.txt")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create SteamCMD script: %w", err)
+	}
+	path := f.Name()
+	cleanup = sync.OnceFunc(func() {
+		// Don't leave credentials behind in freed disk blocks if it can be helped
+		if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			f.Write(make([]byte, len(script)))
+			f.Sync()
+			f.Close()
+		}
+		os.Remove(path)
+		liveRunscripts.Delete(path)
+	})
+	liveRunscripts.Store(path, cleanup)
+
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write SteamCMD script: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write SteamCMD script: %w", err)
+	}
+
+	// SteamCMD resolves relative script paths against its own directory
+	scriptPath := path
+	if abs, err := filepath.Abs(path); err == nil {
+		scriptPath = abs
+	}
+	return []string{"+runscript", scriptPath}, cleanup, nil
+}
+
+// liveRunscripts maps the path of each script not cleaned up yet to its cleanup
+var liveRunscripts sync.Map
+
+// RemoveRunscripts blanks and deletes the scripts of SteamCMD runs still in
+// progress. Call it before exiting without returning from them, e.g. on
+// Ctrl+C, so no password is left on disk.
+func RemoveRunscripts() {
+	liveRunscripts.Range(func(_, cleanup any) bool {
+		cleanup.(func())()
+		return true
+	})
+}
+
+// runscript converts command-line style arguments into a SteamCMD script:
+// one command per line, without the leading +, quoting arguments that
+// contain spaces or quotes
+func runscript(args []string) string {
+	var sb strings.Builder
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "+") {
+			if i > 0 {
+				sb.WriteByte('\n')
+			}
+			sb.WriteString(strings.TrimPrefix(arg, "+"))
+			continue
+		}
+
+		sb.WriteByte(' ')
+		if arg == "" || strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		sb.WriteString(arg)
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
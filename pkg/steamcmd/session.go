@@ -0,0 +1,241 @@
+package steamcmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionItemTimeout bounds how long a session waits for the result of one item
+const sessionItemTimeout = 30 * time.Minute
+
+// sessionQuitTimeout bounds how long a session may take to exit after "quit"
+const sessionQuitTimeout = 10 * time.Second
+
+// errSessionBroken means the session can't be trusted any more and the caller
+// should fall back to running one SteamCMD process per item
+var errSessionBroken = errors.New("persistent SteamCMD session broken")
+
+// session is a long-running SteamCMD process that is fed one command at a time
+// over stdin, saving the startup and login cost of a process per item
+type session struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	lines    chan string // Output lines; closed when the process exits
+	username string
+	account  string // Login reported by SteamCMD, once it has logged in
+	loggedIn bool   // Whether the login finished, after which LoginTimeout no longer applies
+}
+
+// startSession starts SteamCMD logged in as username, or anonymously
+func (c *Client) startSession(username string) (*session, error) {
+	login := username
+	if login == "" {
+		login = "anonymous"
+	}
+
+	// Without +quit SteamCMD stays at its prompt reading commands from stdin
+	cmd := exec.Command(c.SteamCMDPath, append(c.platformArgs(), "+@NoPromptForPassword", "1", "+login", login)...)
+	cmd.Dir = c.WorkingDir
+	cmd.WaitDelay = 5 * time.Second
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &session{
+		cmd:      cmd,
+		stdin:    stdin,
+		lines:    make(chan string, maxTailLines),
+		username: username,
+	}
+
+	go func() {
+		cmd.Wait()
+		pw.Close()
+	}()
+
+	go func() {
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			s.lines <- strings.TrimRight(scanner.Text(), "\r")
+		}
+		io.Copy(io.Discard, pr)
+		close(s.lines)
+	}()
+
+	return s, nil
+}
+
+// download asks SteamCMD to download an item and waits for its result line,
+// or until expired is closed. Errors wrapping errSessionBroken mean the
+// session must be discarded.
+func (s *session) download(item *WorkshopItem, onOutput func(line string), expired <-chan struct{}) error {
+	if _, err := fmt.Fprintf(s.stdin, "workshop_download_item %s %s\n", item.AppID, item.WorkshopID); err != nil {
+		return fmt.Errorf("%w: %v", errSessionBroken, err)
+	}
+
+	// SteamCMD names the account once, while logging in before the first item
+	parser := newOutputParser(item)
+	parser.account = s.account
+	timer := time.NewTimer(sessionItemTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				return fmt.Errorf("%w: SteamCMD exited", errSessionBroken)
+			}
+
+			if onOutput != nil {
+				onOutput(line)
+			}
+			if loggedInRegex.MatchString(line) {
+				s.loggedIn = true
+			}
+
+			if parser.feed(line) {
+				return fmt.Errorf("%w: %s", errSessionBroken, line)
+			}
+			s.account = parser.account
+
+			// Only the result of the item we asked for ends the command
+			if m := parser.success; m != nil && m[1] != item.WorkshopID {
+				parser.success = nil
+			}
+			if m := parser.downloadError; m != nil && m[1] != item.WorkshopID {
+				parser.downloadError = nil
+			}
+			if parser.success != nil || parser.downloadError != nil {
+				return parser.result()
+			}
+
+		case <-timer.C:
+			return fmt.Errorf("%w: no result after %s", errSessionBroken, sessionItemTimeout)
+
+		case <-expired:
+			return fmt.Errorf("%w: timed out", errSessionBroken)
+		}
+	}
+}
+
+// close asks SteamCMD to quit, killing it if it doesn't exit in time
+func (s *session) close() {
+	fmt.Fprintln(s.stdin, "quit")
+	s.stdin.Close()
+
+	timer := time.NewTimer(sessionQuitTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case _, ok := <-s.lines:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			s.cmd.Process.Kill()
+			return
+		}
+	}
+}
+
+// downloadInSession tries to download the item through the persistent session,
+// starting it if needed, under the same login and overall timeouts and
+// circuit breaker as a SteamCMD process per item. It reports whether the
+// outcome is final; otherwise the session has been stopped, so that two
+// SteamCMD processes never share the directory, and the caller falls back to
+// a fresh process. A broken or stuck session turns persistence off for the
+// rest of the client's life.
+func (c *Client) downloadInSession(item *WorkshopItem, username string) (done bool, err error) {
+	if c.session != nil && c.session.username != username {
+		c.Close()
+	}
+
+	c.Breaker.Wait(context.Background())
+
+	if c.session == nil {
+		s, err := c.startSession(username)
+		if err != nil {
+			fmt.Fprintf(c.out(), "Could not start a persistent SteamCMD session, falling back: %v\n", err)
+			c.Persistent = false
+			return false, nil
+		}
+		c.session = s
+	}
+
+	// The login timeout only applies until the session has logged in; the
+	// overall timeout bounds each item, as it bounds each process
+	loginTimeout := c.LoginTimeout
+	if c.session.loggedIn {
+		loginTimeout = 0
+	}
+	expired := make(chan struct{})
+	watch := newPhaseWatch(loginTimeout, c.Timeout, sync.OnceFunc(func() { close(expired) }))
+	onOutput := func(line string) {
+		watch.line(line)
+		if c.OnOutput != nil {
+			c.OnOutput(line)
+		}
+	}
+
+	err = c.session.download(item, onOutput, expired)
+	if timeoutErr := watch.stop(); timeoutErr != nil {
+		err = timeoutErr
+	}
+	c.Breaker.Record(err)
+
+	var timeoutErr *PhaseTimeoutError
+	switch {
+	case errors.As(err, &timeoutErr):
+		fmt.Fprintf(c.out(), "Persistent SteamCMD session stopped, falling back to one process per item: %v\n", err)
+		// A stuck SteamCMD won't act on "quit"; its output is left to drain
+		// in the background, as after a timed-out process
+		c.session.cmd.Process.Kill()
+		c.session = nil
+		c.Persistent = false
+		// A stalled login stalls again in a fresh process
+		return timeoutErr.Phase == PhaseLogin, err
+
+	case errors.Is(err, errSessionBroken):
+		fmt.Fprintf(c.out(), "Persistent SteamCMD session failed, falling back to one process per item: %v\n", err)
+		c.Close()
+		c.Persistent = false
+		return false, nil
+	}
+
+	// An empty success goes through the retry loop instead
+	if err == nil && item.Success && (!c.RetryOnEmpty || EmptyContent(item.PathToFile) == "") {
+		return true, nil
+	}
+
+	// The retry loop runs SteamCMD itself; the next item starts a new session
+	c.Close()
+	return false, nil
+}
+
+// Close stops the persistent SteamCMD session, if one is running
+func (c *Client) Close() {
+	if c.session == nil {
+		return
+	}
+	c.session.close()
+	c.session = nil
+}
@@ -0,0 +1,120 @@
+package steamcmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSessionScript answers both a single +workshop_download_item run and a
+// session fed items on stdin. Items starting with 9 are Access Denied. Each
+// start is logged in starts.log, and a start while another run is still
+// going in overlaps.log.
+const fakeSessionScript = `#!/bin/sh
+[ -e running ] && echo overlap >> overlaps.log
+touch running
+trap 'rm -f running' EXIT
+echo start >> starts.log
+echo "Loading Steam API...OK"
+$LOGIN
+echo "Waiting for user info...OK"
+dl() {
+  case $2 in 9*) echo "ERROR! Download item $2 failed (Access Denied)."; return;; esac
+  mkdir -p "content/$1/$2" && echo hi > "content/$1/$2/a.txt"
+  echo "Success. Downloaded item $2 to \"$PWD/content/$1/$2\" (3 bytes)"
+}
+while [ $# -gt 0 ]; do
+  if [ "$1" = "+workshop_download_item" ]; then dl "$2" "$3"; exit 0; fi
+  shift
+done
+while read cmd app id; do
+  case $cmd in
+    workshop_download_item) dl "$app" "$id";;
+    quit) exit 0;;
+  esac
+done
+`
+
+// newSessionClient returns a persistent client running the fake script,
+// with login replacing $LOGIN
+func newSessionClient(t *testing.T, login string) (*Client, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as SteamCMD")
+	}
+
+	dir := t.TempDir()
+	script := strings.Replace(fakeSessionScript, "$LOGIN", login, 1)
+	if err := os.WriteFile(filepath.Join(dir, "steamcmd.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{
+		SteamCMDPath: filepath.Join(dir, "steamcmd.sh"),
+		WorkingDir:   dir,
+		Persistent:   true,
+		Out:          io.Discard,
+	}
+	t.Cleanup(client.Close)
+	return client, dir
+}
+
+func TestSessionFailureStopsSessionBeforeFallback(t *testing.T) {
+	client, dir := newSessionClient(t, "")
+
+	if _, err := client.DownloadWorkshopItem("4000", "1", ""); err != nil {
+		t.Fatalf("DownloadWorkshopItem(1) unexpected error = %v", err)
+	}
+
+	// The failure is retried in a process of its own, with the session stopped
+	_, err := client.DownloadWorkshopItem("4000", "9", "")
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("DownloadWorkshopItem(9) error = %v, want access denied", err)
+	}
+
+	// The next item gets a new session
+	if _, err := client.DownloadWorkshopItem("4000", "2", ""); err != nil {
+		t.Fatalf("DownloadWorkshopItem(2) unexpected error = %v", err)
+	}
+	if !client.Persistent {
+		t.Error("an item failure shouldn't turn persistence off")
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "starts.log")); err != nil || strings.Count(string(data), "\n") != 3 {
+		t.Errorf("SteamCMD started %q times, want 3: session, single run, new session", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "overlaps.log")); err == nil {
+		t.Error("two SteamCMD processes ran in the same directory at once")
+	}
+}
+
+func TestSessionLoginTimeout(t *testing.T) {
+	client, _ := newSessionClient(t, "sleep 10")
+	client.LoginTimeout = 200 * time.Millisecond
+
+	opened := false
+	client.Breaker = NewBreaker(1, time.Minute, time.Millisecond)
+	client.Breaker.OnOpen = func(int, time.Duration) { opened = true }
+
+	start := time.Now()
+	_, err := client.DownloadWorkshopItem("4000", "1", "")
+
+	var timeoutErr *PhaseTimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Phase != PhaseLogin {
+		t.Fatalf("DownloadWorkshopItem() error = %v, want a login timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("DownloadWorkshopItem() took %s, want it stopped at the login timeout", elapsed)
+	}
+	if client.Persistent {
+		t.Error("a stuck session should turn persistence off")
+	}
+	if !opened {
+		t.Error("the session's failure wasn't recorded by the breaker")
+	}
+}
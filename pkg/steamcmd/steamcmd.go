@@ -3,18 +3,18 @@ package steamcmd
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
-	"bufio"
-
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/backoff"
 	"github.com/sethvargo/go-retry"
 )
 
@@ -22,8 +22,74 @@ import (
 type Client struct {
 	SteamCMDPath string
 	WorkingDir   string
+	MaxRetries   uint64
+
+	// Out receives the client's own messages, such as diagnostics after a
+	// failed run; nil means stdout
+	Out io.Writer
+
+	// OnOutput, if set, receives each line of SteamCMD download output as it is produced
+	OnOutput func(line string)
+
+	// OnRetry, if set, is called before each retry of a download with the retry
+	// number, the configured maximum and the error of the failed attempt
+	OnRetry func(attempt int, max uint64, lastErr error)
+
+	// OnResume, if set, is called on the first progress SteamCMD reports
+	// during a retry, with the retry number, how much of the item it already
+	// had cached and where the previous attempt stopped (zero if it reported
+	// no progress)
+	OnResume func(attempt int, cached, previous Progress)
+
+	// RetryMode selects which failures are retried: RetryLenient (the default)
+	// retries anything that may be transient, RetryStrict only clear network
+	// and server errors
+	RetryMode string
+
+	// Platform, if set, makes SteamCMD download content for another OS than
+	// the host's: PlatformWindows, PlatformMacOS or PlatformLinux
+	Platform string
+
+	// LoginTimeout stops a SteamCMD run that hasn't logged in after this long,
+	// e.g. one waiting for a Steam Guard code that never comes. Timeout bounds
+	// the whole run. Zero disables either.
+	LoginTimeout time.Duration
+	Timeout      time.Duration
+
+	// Breaker, if set, pauses attempts while Steam looks degraded. Share one
+	// between clients so it sees every download of the run.
+	Breaker *Breaker
+
+	// GuardCodes, if set, supplies the Steam Guard code InteractiveLogin
+	// otherwise prompts for
+	GuardCodes GuardCodeSource
+
+	// RetryOnEmpty retries a download SteamCMD reports as successful but that
+	// left no content on disk, which is sometimes a transient Steam glitch.
+	// Once retries run out the empty success is returned as is.
+	RetryOnEmpty bool
+
+	// Persistent keeps one SteamCMD process alive across DownloadWorkshopItem
+	// calls (experimental). Call Close when done.
+	Persistent bool
+
+	session *session
 }
 
+// out returns where the client's own messages go
+func (c *Client) out() io.Writer {
+	if c.Out == nil {
+		return os.Stdout
+	}
+	return c.Out
+}
+
+// Retry modes
+const (
+	RetryLenient = "lenient"
+	RetryStrict  = "strict"
+)
+
 // WorkshopItem represents a downloaded workshop item
 type WorkshopItem struct {
 	AppID      string
@@ -32,8 +98,15 @@ type WorkshopItem struct {
 	PathToFile string
 	SizeBytes  int64
 	ErrorMsg   string
+
+	// Account is the login SteamCMD reported using: a username, or
+	// AccountAnonymous. It's empty when the output didn't say.
+	Account string
 }
 
+// AccountAnonymous is the WorkshopItem.Account of anonymous downloads
+const AccountAnonymous = "anonymous"
+
 // NewClient creates a new SteamCMD client
 func NewClient(steamcmdDir string) (*Client, error) {
 	var steamcmdExe string
@@ -51,6 +124,7 @@ func NewClient(steamcmdDir string) (*Client, error) {
 	return &Client{
 		SteamCMDPath: steamcmdExe,
 		WorkingDir:   steamcmdDir,
+		MaxRetries:   backoff.DefaultMaxRetries,
 	}, nil
 }
 
@@ -62,26 +136,25 @@ func (c *Client) DownloadWorkshopItem(appID, workshopID, username string) (*Work
 		WorkshopID: workshopID,
 	}
 
+	// Try the persistent session first; anything but a final outcome is retried below
+	if c.Persistent {
+		if done, err := c.downloadInSession(item, username); done {
+			return item, classifyError(err)
+		}
+	}
+
 	// Create a context for the retry operation
 	ctx := context.Background()
 
-	// Setup Fibonacci backoff: start at 10s, max 4 retries (5 total attempts)
-	var maxRetries uint64 = 10
-	backoff := retry.NewFibonacci(2 * time.Second)
-	backoff = retry.WithMaxRetries(maxRetries, backoff)
-
-	var attemptCount int
-	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
-		attemptCount++
-		if attemptCount > 1 {
-			fmt.Printf("Retry attempt %d/%d...\n", attemptCount-1, maxRetries)
-		}
-
+	// Retry with the per-category backoff capped at the configured retry count
+	var resume resumeTracker
+	err := c.retryDo(ctx, func(ctx context.Context, attemptCount int) error {
 		var args []string
 		if username != "" {
 			// Use provided username with cached credentials
 			args = []string{
 				"+@ShutdownOnFailedCommand", "1", // Exit on command failure
+				"+@NoPromptForPassword", "1", // Fail instead of waiting for a password without cached credentials
 				"+login", username, // Use cached credentials for this user
 				"+workshop_download_item", appID, workshopID,
 				"+quit",
@@ -96,33 +169,40 @@ func (c *Client) DownloadWorkshopItem(appID, workshopID, username string) (*Work
 			}
 		}
 
-		// Execute SteamCMD
-		cmd := exec.Command(c.SteamCMDPath, args...)
-		cmd.Dir = c.WorkingDir
-
-		var outputBuf bytes.Buffer
-		cmd.Stdout = &outputBuf
-		cmd.Stderr = &outputBuf
-
-		if err := cmd.Run(); err != nil {
-			// Read the default SteamCMD console log for more details
-			consoleLogPath := filepath.Join(c.WorkingDir, "logs", "console_log.txt")
-			logContent := c.readLogFile(consoleLogPath)
-			if logContent != "" && attemptCount == 1 {
-				fmt.Printf("Recent log entries:\n%s\n", c.getRecentLogLines(logContent))
+		// Execute SteamCMD, parsing its output as it streams in
+		parser := newOutputParser(item)
+		resume.watch(c, parser, attemptCount)
+		output, err := c.runStreaming(ctx, args, parser)
+		if username != "" && noCachedCredentials(output) {
+			return fmt.Errorf("not logged on to Steam: no cached credentials for %s. Please run 'workshop login' first to authenticate", username)
+		}
+		if err != nil {
+			// Read the SteamCMD console log, or the captured output without one, for more details
+			_, logContent := c.consoleLog()
+			if attemptCount == 1 {
+				c.printDiagnostics("", logContent, output)
 			}
 
 			// Check for authentication issues
-			if strings.Contains(logContent, "Not logged on") {
+			if strings.Contains(logContent, "Not logged on") || strings.Contains(output, "Not logged on") {
 				return fmt.Errorf("not logged on to Steam. Please run 'workshop login' first to authenticate")
 			}
 
+			// A stalled login stalls again on retry
+			if isLoginTimeout(err) {
+				return err
+			}
+
+			runErr := fmt.Errorf("failed to run SteamCMD: %w\nOutput: %s", err, output)
+			if !c.isRetryableRunError(err) {
+				return runErr
+			}
 			// Make the error retryable to trigger backoff
-			return retry.RetryableError(fmt.Errorf("failed to run SteamCMD: %w\nOutput: %s", err, outputBuf.String()))
+			return retry.RetryableError(runErr)
 		}
 
 		// Parse the output to determine success/failure
-		if err := c.parseOutput(&outputBuf, item); err != nil {
+		if err := parser.result(); err != nil {
 			// Check if this is a retryable error based on the item result
 			if !item.Success && c.isRetryableError(item.ErrorMsg) {
 				return retry.RetryableError(fmt.Errorf("SteamCMD download failed: %s", item.ErrorMsg))
@@ -140,16 +220,65 @@ func (c *Client) DownloadWorkshopItem(appID, workshopID, username string) (*Work
 			return fmt.Errorf("download failed: %s", item.ErrorMsg)
 		}
 
-		return nil
+		return c.checkEmpty(item)
 	})
 
+	if err != nil && c.gaveUpOnEmpty(item, err) {
+		return item, nil
+	}
 	if err != nil {
-		return item, err
+		return item, classifyError(err)
 	}
 
 	return item, nil
 }
 
+// retryDo runs attempt with the client's backoff, numbering attempts from 1 and
+// calling OnRetry before each retry
+func (c *Client) retryDo(ctx context.Context, attempt func(ctx context.Context, attemptCount int) error) error {
+	var attemptCount int
+	var lastErr error
+	var failures []*DownloadError
+
+	// The delay before a retry depends on why the last attempt failed
+	category := backoff.CategoryServer
+	b := backoff.NewCategorized(c.MaxRetries, func() backoff.Category { return category })
+
+	err := retry.Do(ctx, b, func(ctx context.Context) error {
+		attemptCount++
+		if attemptCount > 1 && c.OnRetry != nil {
+			c.OnRetry(attemptCount-1, c.MaxRetries, lastErr)
+		}
+
+		if err := c.Breaker.Wait(ctx); err != nil {
+			return err
+		}
+
+		err := attempt(ctx, attemptCount)
+		c.Breaker.Record(err)
+
+		// Report the cause rather than go-retry's retryable wrapper
+		lastErr = err
+		if inner := errors.Unwrap(err); inner != nil {
+			lastErr = inner
+		}
+		if err != nil {
+			failure := asDownloadError(lastErr)
+			failures = append(failures, failure)
+			category = retryCategory(failure.Kind)
+		}
+		return err
+	})
+
+	// Keep the reason of every attempt when there was more than one. The
+	// final error is the unwrapped cause go-retry gives up with.
+	if err != nil && len(failures) > 1 && errors.Is(err, failures[len(failures)-1].Err) {
+		failures[len(failures)-1] = asDownloadError(err)
+		return &RetryError{Attempts: failures}
+	}
+	return err
+}
+
 // DownloadWorkshopItemWithAuth downloads a workshop item using Steam credentials with retry logic
 func (c *Client) DownloadWorkshopItemWithAuth(appID, workshopID, username, password, guardCode string) (*WorkshopItem, error) {
 	item := &WorkshopItem{
@@ -160,18 +289,9 @@ func (c *Client) DownloadWorkshopItemWithAuth(appID, workshopID, username, passw
 	// Create a context for the retry operation
 	ctx := context.Background()
 
-	// Setup Fibonacci backoff: start at 10s, max 4 retries (5 total attempts)
-	var maxRetries uint64 = 10
-	backoff := retry.NewFibonacci(2 * time.Second)
-	backoff = retry.WithMaxRetries(maxRetries, backoff)
-
-	var attemptCount int
-	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
-		attemptCount++
-		if attemptCount > 1 {
-			fmt.Printf("Retry attempt %d/%d...\n", attemptCount-1, maxRetries)
-		}
-
+	// Retry with the per-category backoff capped at the configured retry count
+	var resume resumeTracker
+	err := c.retryDo(ctx, func(ctx context.Context, attemptCount int) error {
 		// Build SteamCMD arguments with authentication
 		args := []string{
 			"+@ShutdownOnFailedCommand", "1", // Exit on command failure
@@ -187,22 +307,27 @@ func (c *Client) DownloadWorkshopItemWithAuth(appID, workshopID, username, passw
 		// Add download command
 		args = append(args, "+workshop_download_item", appID, workshopID, "+quit")
 
-		// Execute SteamCMD
-		cmd := exec.Command(c.SteamCMDPath, args...)
-		cmd.Dir = c.WorkingDir
+		// Keep the password off the command line
+		args, cleanup, err := c.runscriptArgs(args)
+		if err != nil {
+			return err
+		}
 
-		var outputBuf bytes.Buffer
-		cmd.Stdout = &outputBuf
-		cmd.Stderr = &outputBuf
-
-		if err := cmd.Run(); err != nil {
-			// Read the default SteamCMD console log for more details
-			consoleLogPath := filepath.Join(c.WorkingDir, "logs", "console_log.txt")
-			logContent := c.readLogFile(consoleLogPath)
-			fmt.Printf("SteamCMD failed, check console log: %s\n", consoleLogPath)
-			if logContent != "" {
-				fmt.Printf("Recent log entries:\n%s\n", c.getRecentLogLines(logContent))
+		// Execute SteamCMD, parsing its output as it streams in
+		parser := newOutputParser(item)
+		resume.watch(c, parser, attemptCount)
+		output, err := c.runStreaming(ctx, args, parser)
+		cleanup()
+		if err != nil {
+			// Read the SteamCMD console log, or the captured output without one, for more details
+			consoleLogPath, logContent := c.consoleLog()
+			if consoleLogPath != "" {
+				fmt.Fprintf(c.out(), "SteamCMD failed, check console log: %s\n", consoleLogPath)
+			} else {
+				fmt.Fprintln(c.out(), "SteamCMD failed and wrote no console log")
 			}
+			c.printDiagnostics("", logContent, output)
+			logContent += output
 			// Check if this is a Steam Guard error
 			if strings.Contains(logContent, "steam_guard_code") || strings.Contains(logContent, "Account Logon Denied") {
 				if guardCode == "" {
@@ -213,19 +338,24 @@ func (c *Client) DownloadWorkshopItemWithAuth(appID, workshopID, username, passw
 			if strings.Contains(logContent, "Not logged on") {
 				return fmt.Errorf("not logged on to Steam. Please run 'workshop login' first to authenticate")
 			}
+			// A stalled login stalls again on retry
+			if isLoginTimeout(err) {
+				return err
+			}
+			runErr := fmt.Errorf("failed to run SteamCMD: %w\nOutput: %s", err, output)
+			if !c.isRetryableRunError(err) {
+				return runErr
+			}
 			// Make the error retryable to trigger backoff
-			return retry.RetryableError(fmt.Errorf("failed to run SteamCMD: %w\nOutput: %s", err, outputBuf.String()))
+			return retry.RetryableError(runErr)
 		}
 
 		// Parse the output to determine success/failure
-		if err := c.parseOutput(&outputBuf, item); err != nil {
+		if err := parser.result(); err != nil {
 			// Check if this is a retryable error based on the item result
 			if !item.Success && c.isRetryableError(item.ErrorMsg) {
-				consoleLogPath := filepath.Join(c.WorkingDir, "logs", "console_log.txt")
-				logContent := c.readLogFile(consoleLogPath)
-				if logContent != "" {
-					fmt.Printf("Download failed, recent log entries:\n%s\n", c.getRecentLogLines(logContent))
-				}
+				_, logContent := c.consoleLog()
+				c.printDiagnostics("Download failed. ", logContent, output)
 				return retry.RetryableError(fmt.Errorf("SteamCMD download failed: %s", item.ErrorMsg))
 			}
 			// Non-retryable error (e.g., invalid workshop ID, parsing issue)
@@ -235,27 +365,77 @@ func (c *Client) DownloadWorkshopItemWithAuth(appID, workshopID, username, passw
 		// Check if download was successful
 		if !item.Success {
 			if c.isRetryableError(item.ErrorMsg) {
-				consoleLogPath := filepath.Join(c.WorkingDir, "logs", "console_log.txt")
-				logContent := c.readLogFile(consoleLogPath)
-				if logContent != "" {
-					fmt.Printf("Download failed, recent log entries:\n%s\n", c.getRecentLogLines(logContent))
-				}
+				_, logContent := c.consoleLog()
+				c.printDiagnostics("Download failed. ", logContent, output)
 				return retry.RetryableError(fmt.Errorf("download failed: %s", item.ErrorMsg))
 			}
 			// Non-retryable error
 			return fmt.Errorf("download failed: %s", item.ErrorMsg)
 		}
 
-		return nil
+		return c.checkEmpty(item)
 	})
 
+	if err != nil && c.gaveUpOnEmpty(item, err) {
+		return item, nil
+	}
 	if err != nil {
-		return item, err
+		return item, classifyError(err)
 	}
 
 	return item, nil
 }
 
+// checkEmpty returns a retryable error for a successful download that left no
+// content behind, when RetryOnEmpty is set
+func (c *Client) checkEmpty(item *WorkshopItem) error {
+	if !c.RetryOnEmpty {
+		return nil
+	}
+	if reason := EmptyContent(item.PathToFile); reason != "" {
+		return retry.RetryableError(fmt.Errorf("%w: %s", ErrEmptyDownload, reason))
+	}
+	return nil
+}
+
+// gaveUpOnEmpty reports whether a download only failed because every retry
+// came back empty, in which case the last empty success is what it got
+func (c *Client) gaveUpOnEmpty(item *WorkshopItem, err error) bool {
+	return c.RetryOnEmpty && item.Success && errors.Is(err, ErrEmptyDownload)
+}
+
+// isLoginTimeout reports whether err is a run stopped by LoginTimeout
+func isLoginTimeout(err error) bool {
+	var timeoutErr *PhaseTimeoutError
+	return errors.As(err, &timeoutErr) && timeoutErr.Phase == PhaseLogin
+}
+
+// isRetryableRunError reports whether a SteamCMD run that failed outright is
+// worth retrying. Strict mode only retries one that timed out mid-download.
+func (c *Client) isRetryableRunError(err error) bool {
+	if c.RetryMode != RetryStrict {
+		return true
+	}
+	var timeoutErr *PhaseTimeoutError
+	return errors.As(err, &timeoutErr) && timeoutErr.Phase == PhaseDownload
+}
+
+// strictRetryablePatterns are the clearly transient network and server
+// errors retried in strict mode
+var strictRetryablePatterns = []string{
+	"timeout",
+	"timed out",
+	"connection",
+	"network",
+	"service unavailable",
+	"server busy",
+	"servers are busy",
+	"rate limit",
+	"throttle",
+	"steam servers",
+	"try again",
+}
+
 // isRetryableError determines if an error should trigger a retry
 func (c *Client) isRetryableError(errorMsg string) bool {
 	// Define retryable error patterns (network issues, temporary Steam server problems)
@@ -278,7 +458,20 @@ func (c *Client) isRetryableError(errorMsg string) bool {
 		"please try", // Steam's "please try again" messages
 	}
 
+	// Failures retrying cannot fix are ruled out first, by the same
+	// classification the download error gets, so that e.g.
+	// "Login failed: Invalid Password" isn't retried for containing "failed"
+	if slices.Contains(unretryableKinds, asDownloadError(errors.New(errorMsg)).Kind) {
+		return false
+	}
+
 	errorLower := strings.ToLower(errorMsg)
+
+	// Strict mode treats anything ambiguous, like a bare "Failure", as fatal
+	if c.RetryMode == RetryStrict {
+		retryablePatterns = strictRetryablePatterns
+	}
+
 	for _, pattern := range retryablePatterns {
 		if strings.Contains(errorLower, pattern) {
 			return true
@@ -289,46 +482,16 @@ func (c *Client) isRetryableError(errorMsg string) bool {
 	return false
 }
 
-// parseOutput parses SteamCMD output to determine download status
+// parseOutput parses buffered SteamCMD output to determine download status
 func (c *Client) parseOutput(outputBuf *bytes.Buffer, item *WorkshopItem) error {
 	output := outputBuf.String()
 
-	// Define regular expressions for different scenarios
-	successRegex := regexp.MustCompile(`Success\. Downloaded item (\d+) to "([^"]+)" \((\d+) bytes\)`)
-	downloadFailureRegex := regexp.MustCompile(`ERROR! Download item (\d+) failed \(([^)]+)\)`)
-	loginFailureRegex := regexp.MustCompile(`FAILED \(([^)]+)\)`)
-
-	// Check for success case
-	if matches := successRegex.FindStringSubmatch(output); matches != nil {
-		item.Success = true
-		item.PathToFile = matches[2]
-
-		if bytes, err := strconv.ParseInt(matches[3], 10, 64); err == nil {
-			item.SizeBytes = bytes
-		}
-
-		return nil
-	}
-
-	// Check for download failure
-	if matches := downloadFailureRegex.FindStringSubmatch(output); matches != nil {
-		item.Success = false
-		item.ErrorMsg = fmt.Sprintf("Download failed: %s", matches[2])
-		return nil
+	parser := newOutputParser(item)
+	for _, line := range strings.Split(output, "\n") {
+		parser.feed(strings.TrimRight(line, "\r"))
 	}
 
-	// Check for login failure
-	if matches := loginFailureRegex.FindStringSubmatch(output); matches != nil {
-		item.Success = false
-		item.ErrorMsg = fmt.Sprintf("Login failed: %s", matches[1])
-		return nil
-	}
-
-	// If we can't parse the output, it's an unknown error
-	item.Success = false
-	item.ErrorMsg = "Unknown error occurred"
-
-	return fmt.Errorf("unhandled SteamCMD output: %s", output)
+	return parser.result()
 }
 
 // TestConnection tests if SteamCMD can connect to Steam
@@ -343,12 +506,18 @@ func (c *Client) TestConnection() error {
 	cmd.Stderr = &outputBuf
 
 	err := cmd.Run()
+	output := outputBuf.String()
 	if err != nil {
-		return fmt.Errorf("SteamCMD connection test failed: %w\nOutput: %s", err, outputBuf.String())
+		if strings.Contains(output, "No connection") {
+			return fmt.Errorf("no internet connection or Steam servers unreachable")
+		}
+		// SteamCMD itself didn't run properly, e.g. a broken install
+		return &DownloadError{
+			Kind: ErrSteamCMDFailed,
+			Err:  fmt.Errorf("SteamCMD connection test failed: %w\nOutput: %s", err, output),
+		}
 	}
 
-	output := outputBuf.String()
-
 	// Check for successful login
 	if strings.Contains(output, "Waiting for user info...OK") {
 		return nil
@@ -407,6 +576,16 @@ func (c *Client) GetWorkshopCachePaths() []string {
 	return paths
 }
 
+// GetAppWorkshopCachePaths returns the cache paths belonging to a single app.
+// SteamCMD partitions downloads, temp and content directories by App ID.
+func (c *Client) GetAppWorkshopCachePaths(appID string) []string {
+	var paths []string
+	for _, base := range c.GetWorkshopCachePaths() {
+		paths = append(paths, filepath.Join(base, appID))
+	}
+	return paths
+}
+
 // CheckWorkshopItemExists checks if a workshop item is already downloaded
 func (c *Client) CheckWorkshopItemExists(appID, workshopID string) (bool, string, error) {
 	// Check both local steamcmd path and system Steam path
@@ -443,7 +622,8 @@ func (c *Client) CheckWorkshopItemExists(appID, workshopID string) (bool, string
 	return false, "", nil
 }
 
-// ListDownloadedItems lists all downloaded workshop items
+// ListDownloadedItems lists all downloaded workshop items as App ID -> workshop
+// IDs. ListItems returns the same items with their path, size and metadata.
 func (c *Client) ListDownloadedItems() (map[string][]string, error) {
 	workshopPath := c.GetWorkshopPath()
 	items := make(map[string][]string)
@@ -487,6 +667,31 @@ func (c *Client) ListDownloadedItems() (map[string][]string, error) {
 	return items, nil
 }
 
+// consoleLog returns the path and content of the console_log.txt SteamCMD
+// writes in its directory, or empty strings when there is none. Other Steam
+// log locations, such as the desktop client's, are deliberately ignored: a
+// "Not logged on" found there says nothing about this SteamCMD's session.
+func (c *Client) consoleLog() (path, content string) {
+	path = filepath.Join(c.WorkingDir, "logs", "console_log.txt")
+	if content = c.readLogFile(path); content == "" {
+		return "", ""
+	}
+	return path, content
+}
+
+// printDiagnostics prints the last lines of the console log. Without a log it
+// falls back to the output captured from SteamCMD, so there's always something.
+func (c *Client) printDiagnostics(prefix, logContent, output string) {
+	if logContent != "" {
+		fmt.Fprintf(c.out(), "%sRecent log entries:\n%s\n", prefix, c.getRecentLogLines(logContent))
+		return
+	}
+
+	if output = strings.TrimRight(output, "\n"); output != "" {
+		fmt.Fprintf(c.out(), "%sRecent SteamCMD output (no console log found):\n%s\n", prefix, c.getRecentLogLines(output))
+	}
+}
+
 // readLogFile reads the content of a log file
 func (c *Client) readLogFile(logFile string) string {
 	content, err := os.ReadFile(logFile)
@@ -507,31 +712,41 @@ func (c *Client) getRecentLogLines(logContent string) string {
 
 // GetDebugCommand returns the exact SteamCMD command that would be executed for debugging
 func (c *Client) GetDebugCommand(appID, workshopID string) string {
-	args := []string{
-		c.SteamCMDPath,
+	args := []string{c.SteamCMDPath}
+	args = append(args, c.platformArgs()...)
+	args = append(args,
 		"+@ShutdownOnFailedCommand", "1",
 		"+workshop_download_item", appID, workshopID,
 		"+quit",
-	}
+	)
 	return strings.Join(args, " ")
 }
 
 // GetDebugCommandWithAuth returns the exact SteamCMD command with auth for debugging
 func (c *Client) GetDebugCommandWithAuth(appID, workshopID, username, password string) string {
-	args := []string{
-		c.SteamCMDPath,
+	args := []string{c.SteamCMDPath}
+	args = append(args, c.platformArgs()...)
+	args = append(args,
 		"+@ShutdownOnFailedCommand", "1",
 		"+@NoPromptForPassword", "1",
 		"+login", username, "****", // Hide password in debug output
 		"+workshop_download_item", appID, workshopID,
 		"+quit",
-	}
+	)
 	return strings.Join(args, " ")
 }
 
+// loginContext bounds a login-only SteamCMD run by LoginTimeout, if set
+func (c *Client) loginContext() (context.Context, context.CancelFunc) {
+	if c.LoginTimeout > 0 {
+		return context.WithTimeout(context.Background(), c.LoginTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
 // InteractiveLogin logs into Steam interactively, handling Steam Guard codes
 func (c *Client) InteractiveLogin(username, password string) error {
-	fmt.Println("Starting Steam login process...")
+	fmt.Fprintln(c.out(), "Starting Steam login process...")
 
 	// Build SteamCMD arguments for login
 	args := []string{
@@ -541,37 +756,47 @@ func (c *Client) InteractiveLogin(username, password string) error {
 		"+quit",
 	}
 
-	// Execute SteamCMD
-	cmd := exec.Command(c.SteamCMDPath, args...)
+	// Keep the password off the command line
+	args, cleanup, err := c.runscriptArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Execute SteamCMD. Steam Guard emails sent from now on are for this login.
+	started := time.Now()
+	ctx, cancel := c.loginContext()
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.SteamCMDPath, args...)
 	cmd.Dir = c.WorkingDir
 
 	var outputBuf bytes.Buffer
 	cmd.Stdout = &outputBuf
 	cmd.Stderr = &outputBuf
 
-	err := cmd.Run()
+	err = cmd.Run()
+	// Don't keep the password on disk while waiting for a Steam Guard code
+	cleanup()
 	output := outputBuf.String()
+	if ctx.Err() == context.DeadlineExceeded {
+		return &PhaseTimeoutError{Phase: PhaseLogin, After: c.LoginTimeout}
+	}
 
 	// Check if Steam Guard is required
 	if strings.Contains(output, "steam_guard_code") || strings.Contains(output, "Please check your email") {
-		fmt.Println("📧 Steam Guard authentication required!")
-		fmt.Println("Please check your email for the Steam Guard code.")
-		fmt.Print("Enter Steam Guard code: ")
+		fmt.Fprintln(c.out(), "Steam Guard authentication required!")
 
-		// Read Steam Guard code from user
-		reader := bufio.NewReader(os.Stdin)
-		guardCode, err := reader.ReadString('\n')
+		// Get the Steam Guard code from the configured source or the user
+		guardCode, err := c.guardCode(started)
 		if err != nil {
-			return fmt.Errorf("failed to read Steam Guard code: %w", err)
+			return err
 		}
-		guardCode = strings.TrimSpace(guardCode)
 
 		if guardCode == "" {
 			return fmt.Errorf("Steam Guard code is required")
 		}
 
 		// Login with Steam Guard code
-		fmt.Println("Authenticating with Steam Guard code...")
+		fmt.Fprintln(c.out(), "Authenticating with Steam Guard code...")
 		args = []string{
 			"+@ShutdownOnFailedCommand", "0",
 			"+@NoPromptForPassword", "1",
@@ -579,8 +804,14 @@ func (c *Client) InteractiveLogin(username, password string) error {
 			"+set_steam_guard_code", guardCode,
 			"+quit",
 		}
+		args, guardCleanup, err := c.runscriptArgs(args)
+		if err != nil {
+			return err
+		}
 
-		cmd = exec.Command(c.SteamCMDPath, args...)
+		guardCtx, guardCancel := c.loginContext()
+		defer guardCancel()
+		cmd = exec.CommandContext(guardCtx, c.SteamCMDPath, args...)
 		cmd.Dir = c.WorkingDir
 
 		var finalOutputBuf bytes.Buffer
@@ -588,29 +819,28 @@ func (c *Client) InteractiveLogin(username, password string) error {
 		cmd.Stderr = &finalOutputBuf
 
 		err = cmd.Run()
+		guardCleanup()
 		finalOutput := finalOutputBuf.String()
-
-		// Check for successful login
-		if strings.Contains(finalOutput, "Waiting for user info...OK") || strings.Contains(finalOutput, "OK") {
-			return nil
+		if guardCtx.Err() == context.DeadlineExceeded {
+			return &PhaseTimeoutError{Phase: PhaseLogin, After: c.LoginTimeout}
 		}
 
-		// Check for login errors
-		if strings.Contains(finalOutput, "FAILED") || strings.Contains(finalOutput, "Logon Denied") {
+		switch loggedIn, failed := loginOutcome(finalOutput); {
+		case failed:
 			return fmt.Errorf("authentication failed - check your credentials or Steam Guard code")
+		case loggedIn:
+			return nil
 		}
 
 		return fmt.Errorf("authentication result unclear: %s", finalOutput)
 	}
 
-	// Check for successful login without Steam Guard
-	if strings.Contains(output, "Waiting for user info...OK") || strings.Contains(output, "OK") {
-		return nil
-	}
-
-	// Check for login errors
-	if strings.Contains(output, "FAILED") || strings.Contains(output, "Logon Denied") {
+	// Login without Steam Guard
+	switch loggedIn, failed := loginOutcome(output); {
+	case failed:
 		return fmt.Errorf("authentication failed - check your credentials")
+	case loggedIn:
+		return nil
 	}
 
 	if err != nil {
@@ -619,3 +849,19 @@ func (c *Client) InteractiveLogin(username, password string) error {
 
 	return fmt.Errorf("authentication result unclear: %s", output)
 }
+
+// loginOutcome reads how a login ended from SteamCMD's output. A failure wins
+// over anything else; a bare "OK" proves nothing, since the startup banner
+// always has "Loading Steam API...OK", so success takes a logged-in line.
+func loginOutcome(output string) (loggedIn, failed bool) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "FAILED") || strings.Contains(line, "Logon Denied") {
+			return false, true
+		}
+		if loggedInRegex.MatchString(line) {
+			loggedIn = true
+		}
+	}
+	return loggedIn, false
+}
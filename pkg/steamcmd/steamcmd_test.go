@@ -1,7 +1,17 @@
 package steamcmd
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/backoff"
 )
 
 func TestIsRetryableError(t *testing.T) {
@@ -40,6 +50,51 @@ func TestIsRetryableError(t *testing.T) {
 	}
 }
 
+func TestIsRetryableErrorStrict(t *testing.T) {
+	client := &Client{RetryMode: RetryStrict}
+
+	tests := map[string]bool{
+		"Download failed: Timeout":             true,
+		"no connection to steam":               true,
+		"steam servers unavailable":            true,
+		"Download failed: Failure":             false, // Ambiguous
+		"some error":                           false,
+		"Download failed: No subscription":     false,
+		"connection refused: access denied":    false, // Non-retryable wins
+		"rate limit exceeded, try again later": true,
+	}
+
+	for msg, want := range tests {
+		if got := client.isRetryableError(msg); got != want {
+			t.Errorf("strict isRetryableError(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
+
+func TestIsRetryableErrorUnretryableKinds(t *testing.T) {
+	// Each message contains "failed", which alone would be retried
+	tests := map[string]error{
+		"Download failed: Access Denied":                     ErrAccessDenied,
+		"Download failed: No subscription":                   ErrNotOwned,
+		"Login failed: Invalid Password":                     ErrLoginFailed,
+		"Download failed: File Not Found":                    ErrItemNotFound,
+		"download failed without reporting a result":         ErrUnexpectedOutput,
+		"Download failed: unhandled SteamCMD output: Denied": ErrUnexpectedOutput,
+	}
+
+	for _, mode := range []string{RetryLenient, RetryStrict} {
+		client := &Client{RetryMode: mode}
+		for msg, kind := range tests {
+			if !errors.Is(classifyError(errors.New(msg)), kind) {
+				t.Fatalf("classifyError(%q) should wrap %v", msg, kind)
+			}
+			if client.isRetryableError(msg) {
+				t.Errorf("%s isRetryableError(%q) = true, want false for %v", mode, msg, kind)
+			}
+		}
+	}
+}
+
 func TestIsRetryableErrorCaseInsensitive(t *testing.T) {
 	client := &Client{}
 
@@ -59,3 +114,648 @@ func TestIsRetryableErrorCaseInsensitive(t *testing.T) {
 		}
 	}
 }
+
+func TestParseVersionBanner(t *testing.T) {
+	output := "Redirecting stderr to '/home/user/Steam/logs/stderr.txt'\n" +
+		"Steam Console Client (c) Valve Corporation - version 1716584196\n" +
+		"-- type 'quit' to exit --\n"
+
+	info, err := parseVersionBanner(output)
+	if err != nil {
+		t.Fatalf("parseVersionBanner() error = %v", err)
+	}
+	if info.Version != "1716584196" {
+		t.Errorf("Version = %q, want %q", info.Version, "1716584196")
+	}
+	if info.BuiltAt.Year() != 2024 {
+		t.Errorf("BuiltAt = %v, want a 2024 date", info.BuiltAt)
+	}
+
+	if _, err := parseVersionBanner("Loading Steam API...OK"); err == nil {
+		t.Error("parseVersionBanner() without a banner should fail")
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantSuccess bool
+		wantErr     bool
+		wantMsg     string
+		wantSize    int64
+		wantAccount string
+	}{
+		{
+			name:        "success",
+			output:      "Loading Steam API...OK\r\nSuccess. Downloaded item 2503622437 to \"/steam/steamapps/workshop/content/108600/2503622437\" (1234 bytes)\r\n",
+			wantSuccess: true,
+			wantSize:    1234,
+		},
+		{
+			name:        "success as user",
+			output:      "Logging in user 'someone' [U:1:123] to Steam Public...OK\nWaiting for user info...OK\nSuccess. Downloaded item 2503622437 to \"/steam/steamapps/workshop/content/108600/2503622437\" (1234 bytes)\n",
+			wantSuccess: true,
+			wantSize:    1234,
+			wantAccount: "someone",
+		},
+		{
+			name:        "anonymous failure",
+			output:      "Connecting anonymously to Steam Public...OK\nERROR! Download item 2503622437 failed (Failure).\n",
+			wantMsg:     "Download failed: Failure",
+			wantAccount: AccountAnonymous,
+		},
+		{
+			name:    "download failure",
+			output:  "ERROR! Download item 2503622437 failed (Failure).\n",
+			wantMsg: "Download failed: Failure",
+		},
+		{
+			name:    "license failure",
+			output:  "Loading Steam API...OK\nERROR! Failed to install app '4000' (No subscription)\n",
+			wantMsg: "Download failed: No subscription",
+		},
+		{
+			name:        "login failure",
+			output:      "Logging in user 'someone' to Steam Public...FAILED (Invalid Password)\n",
+			wantMsg:     "Login failed: Invalid Password",
+			wantAccount: "someone",
+		},
+		{
+			name:    "unhandled",
+			output:  "something unexpected\n",
+			wantErr: true,
+			wantMsg: "Unknown error occurred",
+		},
+	}
+
+	client := &Client{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &WorkshopItem{}
+			err := client.parseOutput(bytes.NewBufferString(tt.output), item)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if item.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", item.Success, tt.wantSuccess)
+			}
+			if item.ErrorMsg != tt.wantMsg {
+				t.Errorf("ErrorMsg = %q, want %q", item.ErrorMsg, tt.wantMsg)
+			}
+			if item.SizeBytes != tt.wantSize {
+				t.Errorf("SizeBytes = %d, want %d", item.SizeBytes, tt.wantSize)
+			}
+			if item.Account != tt.wantAccount {
+				t.Errorf("Account = %q, want %q", item.Account, tt.wantAccount)
+			}
+		})
+	}
+}
+
+func TestParseOutputFixtures(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantSuccess bool
+		wantErr     string
+		wantMsg     string
+	}{
+		{fixture: "linux_success.txt", wantSuccess: true},
+		{fixture: "linux_success_same_line.txt", wantSuccess: true},
+		{fixture: "linux_download_failure.txt", wantMsg: "Download failed: Failure"},
+		{fixture: "linux_banner_only.txt", wantErr: "without reporting a result", wantMsg: "Unknown error occurred"},
+	}
+
+	client := &Client{}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			item := &WorkshopItem{}
+			err = client.parseOutput(bytes.NewBuffer(data), item)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("parseOutput() unexpected error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("parseOutput() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if item.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", item.Success, tt.wantSuccess)
+			}
+			if item.ErrorMsg != tt.wantMsg {
+				t.Errorf("ErrorMsg = %q, want %q", item.ErrorMsg, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want error
+	}{
+		{"download failed: Download failed: Access Denied", ErrAccessDenied},
+		{"download failed: Download failed: No subscription", ErrNotOwned},
+		{"download failed: Login failed: Invalid Password", ErrLoginFailed},
+		{"not logged on to Steam. Please run 'workshop login' first", ErrNotLoggedOn},
+		{"depot download failed: File Not Found", ErrItemNotFound},
+		{"failed to parse SteamCMD output: unhandled SteamCMD output: Access denied to dumps", ErrUnexpectedOutput},
+		{"failed to run SteamCMD: exit status 8", ErrSteamCMDFailed},
+		{"download failed: Login Failure: Rate Limit Exceeded", ErrRateLimited},
+		{"download failed: Download failed: Connection to Steam servers lost", ErrNetwork},
+		{"download failed: Download failed: Failure", ErrDownloadFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			err := classifyError(errors.New(tt.msg))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("classifyError(%q) = %v, want it to wrap %v", tt.msg, err.(*DownloadError).Kind, tt.want)
+			}
+			if err.Error() != tt.msg {
+				t.Errorf("Error() = %q, want the original message", err.Error())
+			}
+		})
+	}
+}
+
+func TestRetryCategory(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want backoff.Category
+	}{
+		{"download failed: Login Failure: Rate Limit Exceeded", backoff.CategoryRateLimit},
+		{"download failed: Download failed: Connection to Steam servers lost", backoff.CategoryNetwork},
+		{"SteamCMD download timed out after 30m0s", backoff.CategoryNetwork},
+		{"download failed: Download failed: Failure", backoff.CategoryServer},
+		{"failed to run SteamCMD: exit status 8", backoff.CategoryServer},
+	}
+
+	for _, tt := range tests {
+		if got := retryCategory(asDownloadError(errors.New(tt.msg)).Kind); got != tt.want {
+			t.Errorf("retryCategory(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestIsOutage(t *testing.T) {
+	failure := func(kind error) error {
+		return fmt.Errorf("download failed: %w", &DownloadError{Kind: kind, Err: errors.New("cause")})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", failure(ErrNetwork), true},
+		{"timeout", failure(ErrTimeout), true},
+		{"rate limited", failure(ErrRateLimited), true},
+		{"server failure", failure(ErrDownloadFailed), true},
+		{"retried until a network error", &RetryError{Attempts: []*DownloadError{
+			{Kind: ErrAccessDenied, Err: errors.New("cause")},
+			{Kind: ErrNetwork, Err: errors.New("cause")},
+		}}, true},
+		{"access denied", failure(ErrAccessDenied), false},
+		{"not found", failure(ErrItemNotFound), false},
+		{"not logged on", failure(ErrNotLoggedOn), false},
+		{"empty", failure(ErrEmptyDownload), false},
+		{"unexpected output", failure(ErrUnexpectedOutput), false},
+		{"unclassified local error", errors.New("failed to create output directory"), false},
+		{"success", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsOutage(tt.err); got != tt.want {
+			t.Errorf("IsOutage(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetInstalledItem(t *testing.T) {
+	client := &Client{WorkingDir: "testdata"}
+
+	item, err := client.GetInstalledItem("108600", "2503622437")
+	if err != nil {
+		t.Fatalf("GetInstalledItem() unexpected error = %v", err)
+	}
+	if item.ManifestID != "5467234561928374650" {
+		t.Errorf("ManifestID = %q, want %q", item.ManifestID, "5467234561928374650")
+	}
+	if item.TimeUpdated != "1695123456" || item.SizeBytes != "2049817" {
+		t.Errorf("TimeUpdated/SizeBytes = %q/%q, want 1695123456/2049817", item.TimeUpdated, item.SizeBytes)
+	}
+
+	if _, err := client.GetInstalledItem("108600", "1"); err == nil {
+		t.Error("GetInstalledItem() expected an error for an item that isn't installed")
+	}
+}
+
+func TestParseVDFErrors(t *testing.T) {
+	for _, data := range []string{`"a" {`, `"a" "b" }`, `"a`, `"a"`} {
+		if _, err := parseVDF(data); err == nil {
+			t.Errorf("parseVDF(%q) expected an error", data)
+		}
+	}
+}
+
+func TestListItems(t *testing.T) {
+	client := &Client{WorkingDir: "testdata"}
+
+	items, err := client.ListItems()
+	if err != nil {
+		t.Fatalf("ListItems() unexpected error = %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("ListItems() returned %d items, want 2", len(items))
+	}
+
+	// Sorted by workshop ID; only the second one is recorded in the .acf
+	unrecorded, recorded := items[0], items[1]
+	if unrecorded.WorkshopID != "2400000000" || recorded.WorkshopID != "2503622437" {
+		t.Fatalf("ListItems() order = %s, %s", unrecorded.WorkshopID, recorded.WorkshopID)
+	}
+	if recorded.AppID != "108600" || recorded.SizeBytes != 31 {
+		t.Errorf("AppID/SizeBytes = %s/%d, want 108600/31", recorded.AppID, recorded.SizeBytes)
+	}
+	if recorded.Path != filepath.Join("testdata", "steamapps", "workshop", "content", "108600", "2503622437") {
+		t.Errorf("Path = %q", recorded.Path)
+	}
+	if recorded.ManifestID != "5467234561928374650" || recorded.LastDownloaded.Unix() != 1700000000 || recorded.TimeUpdated.Unix() != 1695123456 {
+		t.Errorf("recorded metadata = %q, %v, %v", recorded.ManifestID, recorded.LastDownloaded, recorded.TimeUpdated)
+	}
+	if unrecorded.ManifestID != "" || unrecorded.LastDownloaded.IsZero() {
+		t.Errorf("unrecorded item: ManifestID = %q, LastDownloaded = %v; want no manifest and the directory time", unrecorded.ManifestID, unrecorded.LastDownloaded)
+	}
+}
+
+func TestFindAppManifest(t *testing.T) {
+	library := filepath.Join("testdata", "library", "steamapps")
+
+	tests := []struct {
+		name      string
+		dir       string
+		wantAppID string
+		wantErr   bool
+	}{
+		{"game directory", filepath.Join(library, "common", "ProjectZomboid"), "108600", false},
+		{"inside a game", filepath.Join(library, "common", "ProjectZomboid", "mods"), "108600", false},
+		{"other game", filepath.Join(library, "common", "GarrysMod"), "4000", false},
+		{"not a game", filepath.Join("testdata", "steamapps"), "", false},
+		{"several manifests", library, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := FindAppManifest(tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindAppManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var appID string
+			if manifest != nil {
+				appID = manifest.AppID
+			}
+			if appID != tt.wantAppID {
+				t.Errorf("FindAppManifest() AppID = %q, want %q", appID, tt.wantAppID)
+			}
+		})
+	}
+}
+
+func TestRetryError(t *testing.T) {
+	err := &RetryError{Attempts: []*DownloadError{
+		asDownloadError(errors.New("download failed: Timeout")),
+		asDownloadError(errors.New("download failed: Failure")),
+		asDownloadError(errors.New("download failed: Timeout")),
+		asDownloadError(errors.New("download failed: Access Denied")),
+	}}
+
+	want := "after 4 attempts (timed out x2, download failed x1, access denied x1): download failed: Access Denied"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, ErrAccessDenied) || errors.Is(err, ErrTimeout) {
+		t.Error("RetryError should classify as its last attempt")
+	}
+	if classifyError(err) != error(err) {
+		t.Error("classifyError() should keep a RetryError as it is")
+	}
+}
+
+func TestPlatformArgs(t *testing.T) {
+	client := &Client{SteamCMDPath: "steamcmd", Platform: PlatformWindows}
+
+	// The platform must be set before SteamCMD logs in
+	want := "steamcmd +@sSteamCmdForcePlatformType windows +@ShutdownOnFailedCommand 1 +@NoPromptForPassword 1 +login user **** +workshop_download_item 4000 1 +quit"
+	if got := client.GetDebugCommandWithAuth("4000", "1", "user", "secret"); got != want {
+		t.Errorf("GetDebugCommandWithAuth() = %q, want %q", got, want)
+	}
+
+	if err := ValidatePlatform("beos"); err == nil {
+		t.Error("ValidatePlatform() expected an error for an unknown platform")
+	}
+}
+
+func TestCachedLogins(t *testing.T) {
+	logins, err := (&Client{WorkingDir: "testdata"}).CachedLogins()
+	if err != nil {
+		t.Fatalf("CachedLogins() unexpected error = %v", err)
+	}
+	if len(logins) != 2 {
+		t.Fatalf("CachedLogins() returned %d logins, want 2", len(logins))
+	}
+
+	recent := logins[0]
+	if recent.Username != "myuser" || !recent.MostRecent || recent.SteamID != "76561198000000002" || recent.LastLogin.Unix() != 1700000000 {
+		t.Errorf("first login = %+v, want the most recent one, myuser", recent)
+	}
+
+	// Without a loginusers.vdf nobody is cached
+	if logins, err := (&Client{WorkingDir: t.TempDir()}).CachedLogins(); err != nil || len(logins) != 0 {
+		t.Errorf("CachedLogins() = %v, %v; want none", logins, err)
+	}
+}
+
+func TestSteamLibraries(t *testing.T) {
+	library, err := filepath.Abs(filepath.Join("testdata", "library"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steamDir := t.TempDir()
+	os.MkdirAll(filepath.Join(steamDir, "steamapps"), 0755)
+	vdf := "\"libraryfolders\"\n{\n\t\"0\"\n\t{\n\t\t\"path\"\t\t\"" + strings.ReplaceAll(steamDir, `\`, `\\`) + "\"\n\t}\n" +
+		"\t\"1\"\n\t{\n\t\t\"path\"\t\t\"" + strings.ReplaceAll(library, `\`, `\\`) + "\"\n\t}\n}\n"
+	if err := os.WriteFile(filepath.Join(steamDir, "steamapps", "libraryfolders.vdf"), []byte(vdf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	libraries, err := SteamLibraries([]string{steamDir})
+	if err != nil {
+		t.Fatalf("SteamLibraries() unexpected error = %v", err)
+	}
+	if len(libraries) != 2 || libraries[0] != steamDir {
+		t.Fatalf("SteamLibraries() = %v, want the install followed by %s", libraries, library)
+	}
+
+	if got, err := GameLibrary(libraries, "108600"); err != nil || got != library {
+		t.Errorf("GameLibrary(108600) = %q, %v; want %q", got, err, library)
+	}
+	if _, err := GameLibrary(libraries, "999"); err == nil {
+		t.Error("GameLibrary(999) expected an error for a game that isn't installed")
+	}
+}
+
+func TestClientManagesItem(t *testing.T) {
+	tests := []struct {
+		library, appID, workshopID string
+		want                       bool
+	}{
+		{"testdata", "108600", "2503622437", true},
+		{"testdata", "108600", "2400000000", false},
+		{filepath.Join("testdata", "library"), "4000", "1", false}, // No appworkshop file
+	}
+
+	for _, tt := range tests {
+		got, err := ClientManagesItem(tt.library, tt.appID, tt.workshopID)
+		if err != nil || got != tt.want {
+			t.Errorf("ClientManagesItem(%s, %s, %s) = %v, %v; want %v", tt.library, tt.appID, tt.workshopID, got, err, tt.want)
+		}
+	}
+}
+
+func TestClientParseOutput(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		wantKind      error
+		wantRetryable bool
+	}{
+		{fixture: "linux_success.txt"},
+		{fixture: "linux_download_failure.txt", wantKind: ErrDownloadFailed, wantRetryable: true},
+		{fixture: "linux_banner_only.txt", wantKind: ErrUnexpectedOutput},
+	}
+
+	client := &Client{RetryMode: RetryLenient}
+	for _, tt := range tests {
+		f, err := os.Open(filepath.Join("testdata", tt.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := client.ParseOutput(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: ParseOutput() unexpected error = %v", tt.fixture, err)
+		}
+
+		if tt.wantKind == nil {
+			if parsed.Err != nil || !parsed.Item.Success {
+				t.Errorf("%s: ParseOutput() = %+v, want a success", tt.fixture, parsed)
+			}
+		} else if !errors.Is(parsed.Err, tt.wantKind) {
+			t.Errorf("%s: Err = %v, want %v", tt.fixture, parsed.Err, tt.wantKind)
+		}
+		if parsed.Retryable != tt.wantRetryable {
+			t.Errorf("%s: Retryable = %v, want %v", tt.fixture, parsed.Retryable, tt.wantRetryable)
+		}
+	}
+}
+
+func TestRunscript(t *testing.T) {
+	args := []string{"+@NoPromptForPassword", "1", "+login", "user", `pa ss"word`, "+workshop_download_item", "108600", "1", "+quit"}
+	want := "@NoPromptForPassword 1\nlogin user \"pa ss\\\"word\"\nworkshop_download_item 108600 1\nquit\n"
+	if got := runscript(args); got != want {
+		t.Errorf("runscript() = %q, want %q", got, want)
+	}
+
+	client := &Client{WorkingDir: t.TempDir()}
+	scriptArgs, cleanup, err := client.runscriptArgs(args)
+	if err != nil {
+		t.Fatalf("runscriptArgs() unexpected error = %v", err)
+	}
+	if len(scriptArgs) != 2 || scriptArgs[0] != "+runscript" {
+		t.Fatalf("runscriptArgs() = %v, want +runscript <file>", scriptArgs)
+	}
+	data, err := os.ReadFile(scriptArgs[1])
+	if err != nil || string(data) != want {
+		t.Errorf("script file = %q, %v; want %q", data, err, want)
+	}
+
+	cleanup()
+	if _, err := os.Stat(scriptArgs[1]); !os.IsNotExist(err) {
+		t.Errorf("script file still exists after cleanup: %v", err)
+	}
+
+	// Scripts of runs that never return are removed on exit
+	scriptArgs, _, err = client.runscriptArgs(args)
+	if err != nil {
+		t.Fatalf("runscriptArgs() unexpected error = %v", err)
+	}
+	RemoveRunscripts()
+	if _, err := os.Stat(scriptArgs[1]); !os.IsNotExist(err) {
+		t.Errorf("script file still exists after RemoveRunscripts: %v", err)
+	}
+}
+
+func TestResumeTracker(t *testing.T) {
+	type resume struct {
+		attempt          int
+		cached, previous Progress
+	}
+	var got []resume
+	client := &Client{OnResume: func(attempt int, cached, previous Progress) {
+		got = append(got, resume{attempt, cached, previous})
+	}}
+
+	attempts := [][]string{
+		{"Update state (0x61) downloading, progress: 10.00 (100 / 1000)", "Update state (0x61) downloading, progress: 60.00 (600 / 1000)", "ERROR! Download item 1 failed (Failure)."},
+		{"Update state (0x61) downloading, progress: 55.00 (550 / 1000)", "Update state (0x61) downloading, progress: 70.00 (700 / 1000)", "ERROR! Download item 1 failed (Failure)."},
+		{"Downloading item 1 ...", "Success. Downloaded item 1 to \"/tmp/1\" (1000 bytes)"},
+	}
+	var tracker resumeTracker
+	for i, lines := range attempts {
+		parser := newOutputParser(&WorkshopItem{})
+		tracker.watch(client, parser, i+1)
+		for _, line := range lines {
+			parser.feed(line)
+		}
+		if len(parser.unrecognized) != 0 {
+			t.Errorf("attempt %d: progress lines reported as unhandled output: %v", i+1, parser.unrecognized)
+		}
+	}
+
+	want := []resume{{attempt: 1, cached: Progress{550, 1000}, previous: Progress{600, 1000}}}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("OnResume calls = %+v, want %+v", got, want)
+	}
+	if p := (Progress{550, 1000}).Percent(); p != 55 {
+		t.Errorf("Percent() = %v, want 55", p)
+	}
+}
+
+func TestEmptyContent(t *testing.T) {
+	dir := t.TempDir()
+
+	empty := filepath.Join(dir, "empty")
+	zero := filepath.Join(dir, "zero")
+	content := filepath.Join(dir, "content")
+	for _, d := range []string{empty, filepath.Join(zero, "sub"), content} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(zero, "sub", "a.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(content, "a.txt"), []byte("hi"), 0644)
+
+	tests := []struct {
+		path      string
+		wantEmpty bool
+	}{
+		{"", true},
+		{filepath.Join(dir, "missing"), true},
+		{empty, true},
+		{zero, true},
+		{content, false},
+	}
+
+	for _, tt := range tests {
+		if got := EmptyContent(tt.path); (got != "") != tt.wantEmpty {
+			t.Errorf("EmptyContent(%q) = %q, want empty: %v", tt.path, got, tt.wantEmpty)
+		}
+	}
+}
+
+func TestRetryOnEmpty(t *testing.T) {
+	item := &WorkshopItem{Success: true, PathToFile: t.TempDir()}
+
+	if err := (&Client{}).checkEmpty(item); err != nil {
+		t.Errorf("checkEmpty() without RetryOnEmpty = %v, want nil", err)
+	}
+
+	client := &Client{RetryOnEmpty: true}
+	err := client.checkEmpty(item)
+	if !errors.Is(err, ErrEmptyDownload) {
+		t.Fatalf("checkEmpty() of an empty directory = %v, want ErrEmptyDownload", err)
+	}
+	if !client.gaveUpOnEmpty(item, errors.Unwrap(err)) {
+		t.Error("gaveUpOnEmpty() = false after retries that all came back empty")
+	}
+	if client.gaveUpOnEmpty(item, ErrTimeout) {
+		t.Error("gaveUpOnEmpty() = true for a timeout")
+	}
+
+	os.WriteFile(filepath.Join(item.PathToFile, "a.txt"), []byte("hi"), 0644)
+	if err := client.checkEmpty(item); err != nil {
+		t.Errorf("checkEmpty() with content = %v, want nil", err)
+	}
+}
+
+func TestConsoleLogOnlyReadsSteamCMDDir(t *testing.T) {
+	home, dir := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+
+	// The desktop client's log says nothing about this SteamCMD
+	clientLogs := filepath.Join(home, ".steam", "steam", "logs")
+	os.MkdirAll(clientLogs, 0755)
+	os.WriteFile(filepath.Join(clientLogs, "console_log.txt"), []byte("Not logged on\n"), 0644)
+
+	client := &Client{WorkingDir: dir}
+	if path, content := client.consoleLog(); path != "" || content != "" {
+		t.Errorf("consoleLog() = %q, %q; want nothing without a log in the SteamCMD directory", path, content)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "logs"), 0755)
+	os.WriteFile(filepath.Join(dir, "logs", "console_log.txt"), []byte("Loading Steam API...OK\n"), 0644)
+	if path, content := client.consoleLog(); path != filepath.Join(dir, "logs", "console_log.txt") || content != "Loading Steam API...OK\n" {
+		t.Errorf("consoleLog() = %q, %q; want the SteamCMD directory's log", path, content)
+	}
+}
+
+func TestLoginOutcome(t *testing.T) {
+	const banner = "Redirecting stderr to '/home/u/Steam/logs/stderr.txt'\n[  0%] Checking for available updates...\n[----] Verifying installation...\nLoading Steam API...OK\n"
+
+	tests := []struct {
+		name         string
+		output       string
+		wantLoggedIn bool
+		wantFailed   bool
+	}{
+		{"banner only", banner, false, false},
+		{"logged in", banner + "Logging in user 'u' to Steam Public...OK\nWaiting for client config...OK\nWaiting for user info...OK\n", true, false},
+		{"invalid password", banner + "Logging in user 'u' to Steam Public...FAILED (Invalid Password)\n", false, true},
+		{"guard code rejected", banner + "Logging in user 'u' to Steam Public...FAILED (Account Logon Denied)\nWaiting for user info...OK\n", false, true},
+		{"windows line endings", strings.ReplaceAll(banner+"Waiting for user info...OK\n", "\n", "\r\n"), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loggedIn, failed := loginOutcome(tt.output)
+			if loggedIn != tt.wantLoggedIn || failed != tt.wantFailed {
+				t.Errorf("loginOutcome() = %v, %v; want %v, %v", loggedIn, failed, tt.wantLoggedIn, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestInteractiveLoginWrongPassword(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as SteamCMD")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "steamcmd.sh")
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+echo "Loading Steam API...OK"
+echo "Logging in user 'u' to Steam Public...FAILED (Invalid Password)"
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{SteamCMDPath: script, WorkingDir: dir, Out: io.Discard}
+	if err := client.InteractiveLogin("u", "wrong"); err == nil {
+		t.Fatal("InteractiveLogin() with a rejected password expected an error")
+	}
+}
@@ -0,0 +1,109 @@
+package steamcmd
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// SteamCMD run phases reported by PhaseTimeoutError
+const (
+	PhaseLogin    = "login"
+	PhaseDownload = "download"
+)
+
+var (
+	// loggedInRegex matches the lines SteamCMD prints once a login completed
+	loggedInRegex = regexp.MustCompile(`^Waiting for user info\.\.\.OK|^Logged in OK`)
+
+	// selfUpdateRegex matches self-update progress, which runs before the login
+	selfUpdateRegex = regexp.MustCompile(`^\[\s*\d+%\] |^\[----\] `)
+)
+
+// PhaseTimeoutError is returned when SteamCMD was stopped for running too long
+type PhaseTimeoutError struct {
+	Phase string        // PhaseLogin or PhaseDownload
+	After time.Duration // The timeout that expired
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("SteamCMD timed out during %s after %s", e.Phase, e.After)
+}
+
+// Is makes phase timeouts match ErrTimeout
+func (e *PhaseTimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// phaseWatch stops a SteamCMD run that stalls during login or overruns the
+// overall timeout, and remembers which phase it was in. Self-update progress
+// extends the login phase, since a first run can update for minutes.
+type phaseWatch struct {
+	mu           sync.Mutex
+	loginTimeout time.Duration
+	loggedIn     bool
+	timers       []*time.Timer
+	login        *time.Timer
+	err          *PhaseTimeoutError
+	cancel       func()
+}
+
+// newPhaseWatch starts the timers of a run; a zero timeout disables it
+func newPhaseWatch(loginTimeout, totalTimeout time.Duration, cancel func()) *phaseWatch {
+	w := &phaseWatch{loginTimeout: loginTimeout, cancel: cancel}
+
+	if loginTimeout > 0 {
+		w.login = time.AfterFunc(loginTimeout, func() { w.expire(loginTimeout) })
+		w.timers = append(w.timers, w.login)
+	}
+	if totalTimeout > 0 {
+		w.timers = append(w.timers, time.AfterFunc(totalTimeout, func() { w.expire(totalTimeout) }))
+	}
+
+	return w
+}
+
+// line tracks the phase from one line of output
+func (w *phaseWatch) line(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.loggedIn {
+		return
+	}
+	switch {
+	case loggedInRegex.MatchString(line):
+		w.loggedIn = true
+		if w.login != nil {
+			w.login.Stop()
+		}
+	case selfUpdateRegex.MatchString(line) && w.login != nil:
+		w.login.Reset(w.loginTimeout)
+	}
+}
+
+func (w *phaseWatch) expire(after time.Duration) {
+	w.mu.Lock()
+	if w.err == nil {
+		phase := PhaseLogin
+		if w.loggedIn {
+			phase = PhaseDownload
+		}
+		w.err = &PhaseTimeoutError{Phase: phase, After: after}
+	}
+	w.mu.Unlock()
+
+	w.cancel()
+}
+
+// stop stops the timers and returns the timeout that ended the run, if any
+func (w *phaseWatch) stop() *PhaseTimeoutError {
+	for _, t := range w.timers {
+		t.Stop()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
@@ -0,0 +1,61 @@
+package steamcmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// OutdatedAfter is the build age after which a SteamCMD install is considered stale.
+// SteamCMD normally self-updates on launch, so a build this old usually means the
+// update is failing and workshop downloads are likely to misbehave.
+const OutdatedAfter = 365 * 24 * time.Hour
+
+// VersionInfo describes the installed SteamCMD build
+type VersionInfo struct {
+	Version string    // Build number as printed in the startup banner
+	BuiltAt time.Time // Build time encoded in the version number (zero if unknown)
+}
+
+// Outdated reports whether the build is older than OutdatedAfter
+func (v *VersionInfo) Outdated() bool {
+	return !v.BuiltAt.IsZero() && time.Since(v.BuiltAt) > OutdatedAfter
+}
+
+// SteamCMDVersion runs SteamCMD and parses the version from its startup banner
+func (c *Client) SteamCMDVersion() (*VersionInfo, error) {
+	cmd := exec.Command(c.SteamCMDPath, "+quit")
+	cmd.Dir = c.WorkingDir
+
+	var outputBuf bytes.Buffer
+	cmd.Stdout = &outputBuf
+	cmd.Stderr = &outputBuf
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run SteamCMD: %w\nOutput: %s", err, outputBuf.String())
+	}
+
+	return parseVersionBanner(outputBuf.String())
+}
+
+// parseVersionBanner extracts the version from a banner such as
+// "Steam Console Client (c) Valve Corporation - version 1716584196"
+func parseVersionBanner(output string) (*VersionInfo, error) {
+	versionRegex := regexp.MustCompile(`Steam Console Client.*version (\d+)`)
+	matches := versionRegex.FindStringSubmatch(output)
+	if matches == nil {
+		return nil, fmt.Errorf("could not find version in SteamCMD output")
+	}
+
+	info := &VersionInfo{Version: matches[1]}
+
+	// SteamCMD versions are the Unix timestamp of the build
+	if ts, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
+		info.BuiltAt = time.Unix(ts, 0).UTC()
+	}
+
+	return info, nil
+}
@@ -0,0 +1,300 @@
+// Package steamguard fetches the Steam Guard codes Steam emails on login, so
+// a first login can run unattended. IMAP implements steamcmd.GuardCodeSource.
+package steamguard
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used for unset IMAP fields
+const (
+	DefaultMailbox      = "INBOX"
+	DefaultSubject      = "Your Steam account"
+	DefaultFrom         = "steampowered.com"
+	DefaultPollInterval = 5 * time.Second
+	DefaultTimeout      = 2 * time.Minute
+)
+
+// IMAP reads Steam Guard codes from the latest Steam email of an IMAP mailbox,
+// over TLS. Providers with two-factor authentication need an app password.
+type IMAP struct {
+	Addr     string // host:port; port 993 is assumed when missing
+	Username string
+	Password string
+
+	Mailbox string // Mailbox to search, DefaultMailbox when empty
+	Subject string // Subject the email must contain, DefaultSubject when empty
+	From    string // Sender the email must contain, DefaultFrom when empty
+
+	// PollInterval is the delay between mailbox checks while waiting for the
+	// email, Timeout how long to wait for it in total
+	PollInterval time.Duration
+	Timeout      time.Duration
+
+	// dial connects to the server; tests replace it
+	dial func(ctx context.Context) (net.Conn, error)
+}
+
+// GuardCode waits for a Steam Guard email received after since and returns
+// the code it holds
+func (m *IMAP) GuardCode(ctx context.Context, since time.Time) (string, error) {
+	if m.Addr == "" || m.Username == "" {
+		return "", fmt.Errorf("IMAP host and username are required")
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	interval := m.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		code, err := m.fetchCode(ctx, since)
+		if err != nil {
+			return "", err
+		}
+		if code != "" {
+			return code, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("no Steam Guard email arrived in %s within %s", m.mailbox(), timeout)
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (m *IMAP) mailbox() string {
+	if m.Mailbox != "" {
+		return m.Mailbox
+	}
+	return DefaultMailbox
+}
+
+// fetchCode checks the mailbox once. It returns "" when no Steam Guard email
+// newer than since is there yet.
+func (m *IMAP) fetchCode(ctx context.Context, since time.Time) (string, error) {
+	conn, err := m.connect(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", m.Addr, err)
+	}
+	defer conn.close()
+
+	if _, err := conn.command("LOGIN %s %s", quote(m.Username), quote(m.Password)); err != nil {
+		return "", fmt.Errorf("IMAP login failed: %w", err)
+	}
+	if _, err := conn.command("EXAMINE %s", quote(m.mailbox())); err != nil {
+		return "", fmt.Errorf("failed to open mailbox %s: %w", m.mailbox(), err)
+	}
+
+	subject, from := m.Subject, m.From
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	if from == "" {
+		from = DefaultFrom
+	}
+	responses, err := conn.command("SEARCH SINCE %s SUBJECT %s FROM %s",
+		since.Format("2-Jan-2006"), quote(subject), quote(from))
+	if err != nil {
+		return "", fmt.Errorf("mailbox search failed: %w", err)
+	}
+
+	var ids []string
+	for _, resp := range responses {
+		if rest, ok := strings.CutPrefix(resp, "SEARCH"); ok {
+			ids = append(ids, strings.Fields(rest)...)
+		}
+	}
+
+	// Newest first: only the latest code is valid
+	for i := len(ids) - 1; i >= 0; i-- {
+		responses, err := conn.command("FETCH %s (INTERNALDATE BODY.PEEK[TEXT])", ids[i])
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch message %s: %w", ids[i], err)
+		}
+		for _, resp := range responses {
+			received, body, ok := parseFetch(resp)
+			if !ok {
+				continue
+			}
+			if received.Before(since.Truncate(time.Second)) {
+				return "", nil
+			}
+			if code, ok := ExtractCode(body); ok {
+				return code, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// connect dials the server and reads its greeting
+func (m *IMAP) connect(ctx context.Context) (*imapConn, error) {
+	dial := m.dial
+	if dial == nil {
+		dial = func(ctx context.Context) (net.Conn, error) {
+			addr := m.Addr
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				addr = net.JoinHostPort(addr, "993")
+			}
+			dialer := &tls.Dialer{}
+			return dialer.DialContext(ctx, "tcp", addr)
+		}
+	}
+
+	nc, err := dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		nc.SetDeadline(deadline)
+	}
+
+	conn := &imapConn{conn: nc, r: bufio.NewReader(nc)}
+	greeting, err := conn.readLine()
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		nc.Close()
+		return nil, fmt.Errorf("unexpected greeting: %s", greeting)
+	}
+	return conn, nil
+}
+
+// imapConn speaks just enough IMAP4rev1 to search and fetch messages
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// literalRegex matches the {size} announcing a literal at the end of a line
+var literalRegex = regexp.MustCompile(`\{(\d+)\}$`)
+
+// command sends a tagged command and returns its untagged responses, with
+// literals inlined and the leading "* " removed
+func (c *imapConn) command(format string, args ...any) ([]string, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var responses []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		// Append literals and the rest of the response they're part of
+		for {
+			match := literalRegex.FindStringSubmatch(line)
+			if match == nil {
+				break
+			}
+			size, _ := strconv.Atoi(match[1])
+			literal := make([]byte, size)
+			if _, err := io.ReadFull(c.r, literal); err != nil {
+				return nil, err
+			}
+			rest, err := c.readLine()
+			if err != nil {
+				return nil, err
+			}
+			line = line[:len(line)-len(match[0])] + quote(string(literal)) + rest
+		}
+
+		if status, ok := strings.CutPrefix(line, tag+" "); ok {
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("%s", status)
+			}
+			return responses, nil
+		}
+		if resp, ok := strings.CutPrefix(line, "* "); ok {
+			responses = append(responses, resp)
+		}
+	}
+}
+
+func (c *imapConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *imapConn) close() {
+	c.command("LOGOUT")
+	c.conn.Close()
+}
+
+// quote renders s as an IMAP quoted string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// unquote reverses quote
+func unquote(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(strings.TrimSuffix(strings.TrimPrefix(s, `"`), `"`))
+}
+
+// fetchRegex matches the INTERNALDATE and BODY[TEXT] of a FETCH response
+var fetchRegex = regexp.MustCompile(`(?s)INTERNALDATE "([^"]+)".*BODY\[TEXT\] ("(?:[^"\\]|\\.)*")`)
+
+// parseFetch extracts the received time and body of a FETCH response
+func parseFetch(resp string) (time.Time, string, bool) {
+	match := fetchRegex.FindStringSubmatch(resp)
+	if match == nil {
+		return time.Time{}, "", false
+	}
+	received, err := time.Parse("_2-Jan-2006 15:04:05 -0700", match[1])
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return received, unquote(match[2]), true
+}
+
+// codeRegex matches a Steam Guard code on a line of its own. Codes are five
+// characters from the alphabet Steam uses, which leaves out look-alikes.
+var codeRegex = regexp.MustCompile(`(?m)^[ \t]*([23456789BCDFGHJKMNPQRTVWXY]{5})[ \t]*$`)
+
+// tagRegex matches HTML tags
+var tagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// ExtractCode finds the Steam Guard code in the body of a Steam email, plain
+// text or HTML, quoted-printable encoded or not
+func ExtractCode(body string) (string, bool) {
+	if decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body))); err == nil {
+		body = string(decoded)
+	}
+	body = tagRegex.ReplaceAllString(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	match := codeRegex.FindStringSubmatch(body)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
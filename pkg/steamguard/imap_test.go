@@ -0,0 +1,106 @@
+package steamguard
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractCode(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"plain.txt", "F3K9P"},
+		{"html_qp.txt", "RTW7Q"},
+	}
+
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := ExtractCode(string(data)); !ok || got != tt.want {
+			t.Errorf("ExtractCode(%s) = %q, %v; want %q", tt.file, got, ok, tt.want)
+		}
+	}
+
+	if got, ok := ExtractCode("Your order 12345 has shipped\r\nHELLO\r\n"); ok {
+		t.Errorf("ExtractCode() = %q for an email without a code", got)
+	}
+}
+
+// fakeServer answers IMAP commands with a mailbox holding messages received
+// at the given times, each with the given body
+func fakeServer(t *testing.T, conn net.Conn, received []time.Time, bodies []string) {
+	t.Helper()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch {
+		case strings.HasPrefix(cmd, "SEARCH"):
+			var ids []string
+			for i := range received {
+				ids = append(ids, fmt.Sprint(i+1))
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(ids, " "))
+		case strings.HasPrefix(cmd, "FETCH"):
+			var id int
+			fmt.Sscanf(cmd, "FETCH %d", &id)
+			body := bodies[id-1]
+			fmt.Fprintf(conn, "* %d FETCH (INTERNALDATE \"%s\" BODY[TEXT] {%d}\r\n%s)\r\n",
+				id, received[id-1].Format("02-Jan-2006 15:04:05 -0700"), len(body), body)
+		case strings.HasPrefix(cmd, "LOGOUT"):
+			fmt.Fprintf(conn, "* BYE\r\n%s OK\r\n", tag)
+			return
+		}
+		fmt.Fprintf(conn, "%s OK done\r\n", tag)
+	}
+}
+
+func TestIMAPGuardCode(t *testing.T) {
+	since := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	received := []time.Time{since.Add(-time.Hour), since.Add(10 * time.Second)}
+	bodies := []string{"Old code:\r\n\r\nBBBBB\r\n", "New code:\r\n\r\nCCCCC\r\n"}
+
+	m := &IMAP{
+		Addr:     "imap.example.com",
+		Username: "me@example.com",
+		Password: "app-password",
+		dial: func(ctx context.Context) (net.Conn, error) {
+			client, server := net.Pipe()
+			go fakeServer(t, server, received, bodies)
+			return client, nil
+		},
+	}
+
+	code, err := m.GuardCode(context.Background(), since)
+	if err != nil {
+		t.Fatalf("GuardCode() unexpected error = %v", err)
+	}
+	if code != "CCCCC" {
+		t.Errorf("GuardCode() = %q, want the newest code CCCCC", code)
+	}
+
+	// Only emails from before the login: wait until the timeout
+	m.Timeout = 50 * time.Millisecond
+	m.PollInterval = 10 * time.Millisecond
+	received = received[:1]
+	bodies = bodies[:1]
+	if code, err := m.GuardCode(context.Background(), since); err == nil {
+		t.Errorf("GuardCode() = %q, want an error when no new email arrives", code)
+	}
+}
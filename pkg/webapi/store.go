@@ -0,0 +1,60 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
+)
+
+// AppDetailsURL is the Steam Store endpoint describing apps. It doesn't require an API key.
+var AppDetailsURL = "https://store.steampowered.com/api/appdetails"
+
+// ErrAppNotFound is returned when the Steam Store has no app with the ID.
+// Some real apps, such as dedicated servers and tools, aren't on the store.
+var ErrAppNotFound = errors.New("no such app on the Steam Store")
+
+type appDetailsResponse map[string]struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Name string `json:"name"`
+	} `json:"data"`
+}
+
+// GetAppName looks up the store name of a Steam app
+func GetAppName(appID string) (string, error) {
+	return getAppName(httpclient.New(10*time.Second), appID)
+}
+
+// VerifyApp is GetAppName with a single short attempt, for checks that
+// shouldn't hold up a download when Steam can't be reached
+func VerifyApp(appID string) (string, error) {
+	client := httpclient.New(5 * time.Second)
+	client.MaxRetries = 0
+	return getAppName(client, appID)
+}
+
+func getAppName(client *httpclient.Client, appID string) (string, error) {
+	query := url.Values{"appids": {appID}, "filters": {"basic"}}
+	resp, err := client.Get(context.Background(), AppDetailsURL+"?"+query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to query Steam Store API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded appDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return "", fmt.Errorf("failed to decode Steam Store API response: %w", err)
+	}
+
+	app, ok := decoded[appID]
+	if !ok || !app.Success || app.Data.Name == "" {
+		return "", fmt.Errorf("%w: app %s", ErrAppNotFound, appID)
+	}
+
+	return app.Data.Name, nil
+}
@@ -0,0 +1,30 @@
+package webapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAppName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch id := r.URL.Query().Get("appids"); id {
+		case "108600":
+			w.Write([]byte(`{"108600": {"success": true, "data": {"name": "Project Zomboid"}}}`))
+		default:
+			w.Write([]byte(`{"` + id + `": {"success": false}}`))
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { AppDetailsURL = url }(AppDetailsURL)
+	AppDetailsURL = server.URL
+
+	if name, err := GetAppName("108600"); err != nil || name != "Project Zomboid" {
+		t.Errorf("GetAppName(108600) = %q, %v; want Project Zomboid", name, err)
+	}
+	if _, err := GetAppName("1086000"); !errors.Is(err, ErrAppNotFound) {
+		t.Errorf("GetAppName(1086000) error = %v, want ErrAppNotFound", err)
+	}
+}
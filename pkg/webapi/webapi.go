@@ -0,0 +1,121 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davidroman0O/steam-workshop-downloader/pkg/httpclient"
+)
+
+// PublishedFileDetailsURL is the Steam Web API endpoint describing workshop items.
+// It doesn't require an API key.
+var PublishedFileDetailsURL = "https://api.steampowered.com/ISteamRemoteStorage/GetPublishedFileDetails/v1/"
+
+// resultOK is the Steam EResult value for success
+const resultOK = 1
+
+// FileDetails describes a workshop item as reported by the Web API
+type FileDetails struct {
+	WorkshopID  string
+	AppID       string
+	Title       string
+	SizeBytes   int64
+	TimeUpdated time.Time
+}
+
+// uint64String accepts a JSON number or a numeric string
+type uint64String string
+
+func (v *uint64String) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*v = ""
+		return nil
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*v = uint64String(s)
+	return nil
+}
+
+type publishedFileDetailsResponse struct {
+	Response struct {
+		Result  int `json:"result"`
+		Details []struct {
+			PublishedFileID uint64String `json:"publishedfileid"`
+			Result          int          `json:"result"`
+			ConsumerAppID   uint64String `json:"consumer_app_id"`
+			Title           string       `json:"title"`
+			FileSize        uint64String `json:"file_size"`
+			TimeUpdated     int64        `json:"time_updated"`
+		} `json:"publishedfiledetails"`
+	} `json:"response"`
+}
+
+// GetPublishedFileDetails looks up a workshop item through the Web API
+func GetPublishedFileDetails(workshopID string) (*FileDetails, error) {
+	details, err := GetPublishedFileDetailsBatch([]string{workshopID})
+	if err != nil {
+		return nil, err
+	}
+
+	d, ok := details[workshopID]
+	if !ok {
+		return nil, fmt.Errorf("Steam Web API could not find item %s", workshopID)
+	}
+	return d, nil
+}
+
+// GetPublishedFileDetailsBatch looks up several workshop items in one request.
+// Items the Web API doesn't know are left out of the returned map.
+func GetPublishedFileDetailsBatch(workshopIDs []string) (map[string]*FileDetails, error) {
+	client := httpclient.New(10 * time.Second)
+
+	form := url.Values{"itemcount": {strconv.Itoa(len(workshopIDs))}}
+	for i, id := range workshopIDs {
+		form.Set(fmt.Sprintf("publishedfileids[%d]", i), id)
+	}
+
+	resp, err := client.PostForm(context.Background(), PublishedFileDetailsURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Steam Web API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded publishedFileDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode Steam Web API response: %w", err)
+	}
+
+	if len(decoded.Response.Details) == 0 {
+		return nil, fmt.Errorf("Steam Web API returned no item details")
+	}
+
+	details := make(map[string]*FileDetails, len(decoded.Response.Details))
+	for _, d := range decoded.Response.Details {
+		if d.Result != resultOK {
+			continue
+		}
+
+		item := &FileDetails{
+			WorkshopID: string(d.PublishedFileID),
+			AppID:      string(d.ConsumerAppID),
+			Title:      d.Title,
+		}
+		if size, err := strconv.ParseInt(string(d.FileSize), 10, 64); err == nil {
+			item.SizeBytes = size
+		}
+		if d.TimeUpdated > 0 {
+			item.TimeUpdated = time.Unix(d.TimeUpdated, 0).UTC()
+		}
+		details[item.WorkshopID] = item
+	}
+
+	return details, nil
+}